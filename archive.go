@@ -0,0 +1,395 @@
+package bagit
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Format is a serialization format of bags, see Serialize.
+type Format string
+
+const (
+	FormatZip     Format = "zip"
+	FormatTar     Format = "tar"
+	FormatTarGzip Format = "tar.gz"
+	FormatTarZstd Format = "tar.zst"
+)
+
+// detectFormat detects the serialization of a bag from the first bytes of the
+// file. It returns an empty Format if the file is not a supported archive.
+func detectFormat(header []byte) Format {
+	switch {
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")), bytes.HasPrefix(header, []byte("PK\x05\x06")):
+		return FormatZip
+	case bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		return FormatTarGzip
+	case bytes.HasPrefix(header, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		return FormatTarZstd
+	case len(header) >= 262 && string(header[257:262]) == "ustar":
+		return FormatTar
+	default:
+		return ""
+	}
+}
+
+// Default limits of the extraction of serialized bags, see
+// WithExtractionLimits.
+const (
+	defaultMaxExtractedBytes   = 64 << 30
+	defaultMaxExtractedEntries = 1_000_000
+)
+
+// extractLimits bounds the extraction of a serialized bag. Zero values use the
+// defaults.
+type extractLimits struct {
+	maxBytes   int64
+	maxEntries int
+}
+
+// WithExtractionLimits bounds the extraction of serialized bags to maxBytes
+// of file contents and maxEntries files and directories, so that a small
+// compressed archive cannot fill the temporary directory. Archives exceeding
+// a limit are reported with a *ValidationError. Values lower than one keep the
+// defaults of 64 GiB and one million entries.
+func WithExtractionLimits(maxBytes int64, maxEntries int) ValidatorOption {
+	return runnerOption(func(cfg *runnerConfig) {
+		cfg.extractLimits = extractLimits{maxBytes: max(maxBytes, 0), maxEntries: max(maxEntries, 0)}
+	})
+}
+
+// withDefaults returns l with its zero values replaced by the defaults.
+func (l extractLimits) withDefaults() extractLimits {
+	if l.maxBytes == 0 {
+		l.maxBytes = defaultMaxExtractedBytes
+	}
+	if l.maxEntries == 0 {
+		l.maxEntries = defaultMaxExtractedEntries
+	}
+
+	return l
+}
+
+// invalidArchiveError reports a serialized bag that cannot be extracted.
+type invalidArchiveError struct {
+	msg string
+}
+
+func (e *invalidArchiveError) Error() string {
+	return e.msg
+}
+
+func invalidArchive(format string, a ...any) error {
+	return &invalidArchiveError{msg: fmt.Sprintf(format, a...)}
+}
+
+// extractedBag is a serialized bag extracted into a temporary directory.
+type extractedBag struct {
+	root   string // Temporary directory, removed by cleanup, if extracted.
+	path   string // Top-level directory of the archive, i.e. the bag.
+	format Format // Serialization of the archive.
+}
+
+func (b *extractedBag) cleanup() error {
+	if b.root == "" {
+		return nil
+	}
+
+	return os.RemoveAll(b.root)
+}
+
+// extractPath extracts the bag at path if it is a serialized bag, see
+// extractBag. Otherwise, the returned bag is path itself, with no format and
+// nothing to clean up.
+func extractPath(ctx context.Context, path string, limits extractLimits) (*extractedBag, error) {
+	if st, err := os.Stat(path); err != nil || !st.Mode().IsRegular() {
+		return &extractedBag{path: path}, nil
+	}
+
+	bag, err := extractBag(ctx, path, limits)
+	if err != nil {
+		return nil, err
+	}
+	if bag == nil {
+		return &extractedBag{path: path}, nil
+	}
+
+	return bag, nil
+}
+
+// extractBag extracts the serialized bag at name, a zip, tar, or gzip or
+// Zstandard compressed tar file, into a new temporary directory. It returns
+// nil if name is not a supported archive, leaving it to bagit-python to
+// report.
+//
+// Following RFC 8493 section 4.2, the archive must hold a single top-level
+// directory, which is the bag. Archives breaking this rule, or holding unsafe
+// or unsupported entries such as links, or exceeding limits, are reported with
+// a *ValidationError.
+func extractBag(ctx context.Context, name string, limits extractLimits) (_ *extractedBag, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	header, err := bufio.NewReader(f).Peek(512)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	format := detectFormat(header)
+	if format == "" {
+		return nil, nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	root, err := os.MkdirTemp("", "bagit-gython-bag-*")
+	if err != nil {
+		return nil, fmt.Errorf("make extraction dir: %v", err)
+	}
+	bag := &extractedBag{root: root, format: format}
+	defer func() {
+		if err != nil {
+			_ = bag.cleanup()
+		}
+	}()
+
+	x := &extractor{ctx: ctx, root: root, limits: limits.withDefaults()}
+	switch format {
+	case FormatZip:
+		err = x.zip(f)
+	case FormatTar:
+		err = x.tar(f)
+	case FormatTarGzip:
+		var zr *gzip.Reader
+		zr, err = gzip.NewReader(f)
+		if err != nil {
+			err = invalidArchive("read gzip stream: %v", err)
+			break
+		}
+		defer zr.Close()
+		err = x.tar(zr)
+	case FormatTarZstd:
+		var zr *zstd.Decoder
+		zr, err = zstd.NewReader(f, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			err = invalidArchive("read zstd stream: %v", err)
+			break
+		}
+		defer zr.Close()
+		err = x.tar(zr)
+	}
+	if err == nil {
+		bag.path, err = x.bagPath()
+	}
+
+	var aerr *invalidArchiveError
+	if errors.As(err, &aerr) {
+		return nil, newValidationError(&BagError{
+			Type:    "BagValidationError",
+			Message: fmt.Sprintf("Invalid serialized bag %s: %s", name, aerr.msg),
+			Path:    name,
+		}, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return bag, nil
+}
+
+// extractor writes archive entries below root.
+type extractor struct {
+	ctx     context.Context
+	root    string
+	limits  extractLimits
+	bytes   int64 // File contents extracted so far.
+	entries int   // Files and directories extracted so far.
+}
+
+func (x *extractor) tar(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return invalidArchive("read tar entry: %v", err)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = x.dir(hdr.Name)
+		case tar.TypeReg:
+			err = x.file(hdr.Name, tr)
+		case tar.TypeXGlobalHeader:
+			continue
+		default:
+			err = invalidArchive("unsupported entry type for %q", hdr.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (x *extractor) zip(f *os.File) error {
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	zr, err := zip.NewReader(f, st.Size())
+	if err != nil {
+		return invalidArchive("read zip: %v", err)
+	}
+
+	for _, zf := range zr.File {
+		switch mode := zf.Mode(); {
+		case mode.IsDir():
+			err = x.dir(zf.Name)
+		case mode.IsRegular():
+			err = x.zipFile(zf)
+		default:
+			err = invalidArchive("unsupported entry type for %q", zf.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (x *extractor) zipFile(zf *zip.File) error {
+	rc, err := zf.Open()
+	if err != nil {
+		return invalidArchive("open %q: %v", zf.Name, err)
+	}
+	defer rc.Close()
+
+	return x.file(zf.Name, rc)
+}
+
+// path validates the name of an archive entry and returns the extraction
+// path.
+func (x *extractor) path(name string) (string, error) {
+	if err := x.ctx.Err(); err != nil {
+		return "", err
+	}
+	if x.entries++; x.entries > x.limits.maxEntries {
+		return "", invalidArchive("more than %d entries", x.limits.maxEntries)
+	}
+
+	clean := path.Clean(strings.TrimSuffix(name, "/"))
+	if !filepath.IsLocal(filepath.FromSlash(clean)) {
+		return "", invalidArchive("unsafe entry name %q", name)
+	}
+
+	return filepath.Join(x.root, filepath.FromSlash(clean)), nil
+}
+
+func (x *extractor) dir(name string) error {
+	p, err := x.path(name)
+	if err != nil {
+		return err
+	}
+
+	return os.MkdirAll(p, 0o700)
+}
+
+func (x *extractor) file(name string, r io.Reader) error {
+	p, err := x.path(name)
+	if err != nil {
+		return err
+	}
+	if p == x.root {
+		return invalidArchive("unsafe entry name %q", name)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, fs.ErrExist) {
+		return invalidArchive("duplicate entry %q", name)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Read one extra byte to detect entries exceeding the limit.
+	left := x.limits.maxBytes - x.bytes
+	n, err := io.Copy(f, io.LimitReader(ctxReader{ctx: x.ctx, r: entryReader{r}}, left+1))
+	x.bytes += n
+	if err == nil && n > left {
+		err = invalidArchive("more than %d bytes of file contents", x.limits.maxBytes)
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// bagPath returns the single top-level directory of the archive.
+func (x *extractor) bagPath() (string, error) {
+	entries, err := os.ReadDir(x.root)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) != 1 {
+		return "", invalidArchive("expected a single top-level directory, found %d entries", len(entries))
+	}
+	if !entries[0].IsDir() {
+		return "", invalidArchive("expected a single top-level directory, found file %q", entries[0].Name())
+	}
+
+	return filepath.Join(x.root, entries[0].Name()), nil
+}
+
+// entryReader reads an archive entry, reporting read errors as an invalid
+// archive.
+type entryReader struct {
+	r io.Reader
+}
+
+func (r entryReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && err != io.EOF {
+		return n, invalidArchive("read entry: %v", err)
+	}
+
+	return n, err
+}
+
+// ctxReader reads from r until ctx is done.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return r.r.Read(p)
+}
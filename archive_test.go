@@ -0,0 +1,198 @@
+package bagit_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/artefactual-labs/bagit-gython"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+type archiveEntry struct {
+	name string
+	body string
+}
+
+// validBagEntries returns the entries of internal/testdata/valid-bag below
+// the top-level directory top.
+func validBagEntries(t *testing.T, top string) []archiveEntry {
+	t.Helper()
+
+	entries := []archiveEntry{{name: top + "/"}}
+	root := "internal/testdata/valid-bag"
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil || path == root {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		name := top + "/" + filepath.ToSlash(rel)
+		if d.IsDir() {
+			entries = append(entries, archiveEntry{name: name + "/"})
+			return nil
+		}
+		blob, err := os.ReadFile(path)
+		entries = append(entries, archiveEntry{name: name, body: string(blob)})
+		return err
+	})
+	assert.NilError(t, err)
+
+	return entries
+}
+
+// writeArchive writes entries to a new zip, tar or tar.gz file, names ending
+// with a slash are directories.
+func writeArchive(t *testing.T, format string, entries []archiveEntry) string {
+	t.Helper()
+
+	dir := fs.NewDir(t, "")
+	path := dir.Join("bag.bin") // Formats are detected by content.
+	f, err := os.Create(path)
+	assert.NilError(t, err)
+	defer f.Close()
+
+	if format == "zip" {
+		zw := zip.NewWriter(f)
+		for _, e := range entries {
+			w, err := zw.Create(e.name)
+			assert.NilError(t, err)
+			_, err = io.WriteString(w, e.body)
+			assert.NilError(t, err)
+		}
+		assert.NilError(t, zw.Close())
+		return path
+	}
+
+	var w io.Writer = f
+	if format == "tar.gz" {
+		zw := gzip.NewWriter(f)
+		defer func() { assert.NilError(t, zw.Close()) }()
+		w = zw
+	}
+	tw := tar.NewWriter(w)
+	defer func() { assert.NilError(t, tw.Close()) }()
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0o644, Size: int64(len(e.body)), Typeflag: tar.TypeReg}
+		if e.name[len(e.name)-1] == '/' {
+			hdr.Typeflag, hdr.Mode, hdr.Size = tar.TypeDir, 0o755, 0
+		}
+		assert.NilError(t, tw.WriteHeader(hdr))
+		_, err := io.WriteString(tw, e.body)
+		assert.NilError(t, err)
+	}
+
+	return path
+}
+
+func TestValidateSerializedBag(t *testing.T) {
+	t.Parallel()
+
+	b := setUp(t)
+
+	for _, format := range []string{"zip", "tar", "tar.gz"} {
+		t.Run("Validates "+format, func(t *testing.T) {
+			path := writeArchive(t, format, validBagEntries(t, "valid-bag"))
+			assert.NilError(t, b.Validate(path))
+		})
+	}
+
+	t.Run("Reports bag errors", func(t *testing.T) {
+		entries := validBagEntries(t, "bag")
+		for i, e := range entries {
+			if e.name == "bag/data/hola.txt" {
+				entries[i].body = "hola"
+			}
+		}
+		path := writeArchive(t, "tar.gz", entries)
+
+		err := b.Validate(path, bagit.WithCompletenessOnly())
+		var verr *bagit.ValidationError
+		assert.Assert(t, errors.As(err, &verr))
+		assert.ErrorContains(t, err, "Payload-Oxum validation failed")
+
+		var berr *bagit.BagError
+		assert.Assert(t, errors.As(err, &berr))
+		assert.Equal(t, berr.Path, path)
+	})
+
+	for name, tc := range map[string]struct {
+		entries []archiveEntry
+		msg     string
+	}{
+		"Rejects multiple top-level entries": {
+			entries: append(validBagEntries(t, "bag"), archiveEntry{name: "README.txt", body: "hi"}),
+			msg:     "expected a single top-level directory, found 2 entries",
+		},
+		"Rejects top-level files": {
+			entries: []archiveEntry{{name: "bagit.txt", body: "BagIt-Version: 1.0\n"}},
+			msg:     `expected a single top-level directory, found file "bagit.txt"`,
+		},
+		"Rejects unsafe entries": {
+			entries: []archiveEntry{{name: "bag/../../escape.txt", body: "hi"}},
+			msg:     `unsafe entry name "bag/../../escape.txt"`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := writeArchive(t, "tar", tc.entries)
+
+			err := b.Validate(path)
+			assert.ErrorIs(t, err, bagit.ErrInvalid)
+			assert.ErrorContains(t, err, tc.msg)
+
+			var serr *bagit.StructureError
+			assert.Assert(t, errors.As(err, &serr))
+		})
+	}
+
+	t.Run("Rejects truncated archives", func(t *testing.T) {
+		path := writeArchive(t, "tar.gz", validBagEntries(t, "bag"))
+		st, err := os.Stat(path)
+		assert.NilError(t, err)
+		assert.NilError(t, os.Truncate(path, st.Size()/2))
+
+		err = b.Validate(path)
+		assert.ErrorIs(t, err, bagit.ErrInvalid)
+		assert.ErrorContains(t, err, "Invalid serialized bag")
+	})
+
+	t.Run("Limits extraction", func(t *testing.T) {
+		path := writeArchive(t, "tar.gz", validBagEntries(t, "bag"))
+
+		for name, tc := range map[string]struct {
+			opt bagit.ValidatorOption
+			msg string
+		}{
+			"size":    {opt: bagit.WithExtractionLimits(10, 0), msg: "more than 10 bytes of file contents"},
+			"entries": {opt: bagit.WithExtractionLimits(0, 3), msg: "more than 3 entries"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				b, err := bagit.NewBagIt(tc.opt)
+				assert.NilError(t, err)
+				t.Cleanup(func() {
+					assert.NilError(t, b.Cleanup())
+				})
+
+				err = b.Validate(path)
+				assert.ErrorIs(t, err, bagit.ErrInvalid)
+				assert.ErrorContains(t, err, tc.msg)
+			})
+		}
+	})
+
+	t.Run("Reports regular files that are not archives", func(t *testing.T) {
+		dir := fs.NewDir(t, "", fs.WithFile("bag.txt", "not a bag"))
+
+		err := b.Validate(dir.Join("bag.txt"))
+		assert.ErrorIs(t, err, bagit.ErrInvalid)
+		assert.ErrorContains(t, err, "Expected bagit.txt does not exist")
+	})
+}
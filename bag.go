@@ -0,0 +1,92 @@
+package bagit
+
+// Bag describes the contents of a bag as read by bagit-python, see
+// BagIt.Inspect. Reading a bag does not validate it.
+type Bag struct {
+	// Path is the absolute path of the bag.
+	Path string
+
+	// Version is the BagIt version declared in bagit.txt, e.g. "1.0".
+	Version string
+
+	// Encoding is the character encoding of the tag files declared in
+	// bagit.txt, e.g. "UTF-8".
+	Encoding string
+
+	// BagInfo lists the tags of bag-info.txt in file order.
+	BagInfo BagInfo
+
+	// Algorithms lists the algorithms of the manifests and tag manifests,
+	// sorted by name.
+	Algorithms []Algorithm
+
+	// PayloadOxum is the Payload-Oxum recorded in bag-info.txt, or nil if it
+	// is missing or malformed. The first value is used when repeated.
+	PayloadOxum *PayloadOxum
+
+	// Manifests lists the entries of the payload manifests per algorithm,
+	// sorted by path.
+	Manifests map[Algorithm][]ManifestEntry
+
+	// TagManifests lists the entries of the tag manifests per algorithm,
+	// sorted by path.
+	TagManifests map[Algorithm][]ManifestEntry
+
+	// Fetch lists the entries of fetch.txt.
+	Fetch []FetchEntry
+
+	// MissingOptionalTagFiles lists the tag files listed in the tag manifests
+	// that do not exist.
+	MissingOptionalTagFiles []string
+}
+
+// ManifestEntry is a line of a manifest file.
+type ManifestEntry struct {
+	Path     string `json:"path"` // Relative to the bag, e.g. "data/file.txt".
+	Checksum string `json:"checksum"`
+}
+
+// FetchEntry is a line of fetch.txt, describing a payload file to be fetched
+// from a URL.
+type FetchEntry struct {
+	URL  string `json:"url"`
+	Size int64  `json:"size"` // Size in bytes, -1 if unknown.
+	Path string `json:"path"` // Relative to the bag, e.g. "data/file.txt".
+}
+
+type inspectRequest struct {
+	Path string `json:"path"`
+}
+
+type inspectResponse struct {
+	errorResponse
+	Path                    string                        `json:"path"`
+	Version                 string                        `json:"version"`
+	Encoding                string                        `json:"encoding"`
+	BagInfo                 BagInfo                       `json:"bag_info"`
+	Algorithms              []Algorithm                   `json:"algorithms"`
+	PayloadOxum             string                        `json:"payload_oxum"`
+	Manifests               map[Algorithm][]ManifestEntry `json:"manifests"`
+	TagManifests            map[Algorithm][]ManifestEntry `json:"tag_manifests"`
+	Fetch                   []FetchEntry                  `json:"fetch"`
+	MissingOptionalTagFiles []string                      `json:"missing_optional_tagfiles"`
+}
+
+func (r inspectResponse) bag() *Bag {
+	bag := &Bag{
+		Path:                    r.Path,
+		Version:                 r.Version,
+		Encoding:                r.Encoding,
+		BagInfo:                 r.BagInfo,
+		Algorithms:              r.Algorithms,
+		Manifests:               r.Manifests,
+		TagManifests:            r.TagManifests,
+		Fetch:                   r.Fetch,
+		MissingOptionalTagFiles: r.MissingOptionalTagFiles,
+	}
+	if oxum, err := ParsePayloadOxum(r.PayloadOxum); err == nil {
+		bag.PayloadOxum = &oxum
+	}
+
+	return bag
+}
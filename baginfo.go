@@ -0,0 +1,88 @@
+package bagit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Algorithm is a checksum algorithm name as used in manifest file names, e.g.
+// "sha256" in manifest-sha256.txt.
+type Algorithm string
+
+const (
+	MD5    Algorithm = "md5"
+	SHA1   Algorithm = "sha1"
+	SHA224 Algorithm = "sha224"
+	SHA256 Algorithm = "sha256"
+	SHA384 Algorithm = "sha384"
+	SHA512 Algorithm = "sha512"
+)
+
+// Tag is a single label and value pair of a tag file such as bag-info.txt.
+type Tag struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// BagInfo is an ordered list of bag-info.txt tags. A label may be repeated to
+// record multiple values.
+type BagInfo []Tag
+
+// Get returns the first value recorded for label.
+func (bi BagInfo) Get(label string) (string, bool) {
+	for _, tag := range bi {
+		if tag.Label == label {
+			return tag.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// Values returns every value recorded for label, in order.
+func (bi BagInfo) Values(label string) []string {
+	var values []string
+	for _, tag := range bi {
+		if tag.Label == label {
+			values = append(values, tag.Value)
+		}
+	}
+
+	return values
+}
+
+// Add appends a value for label, keeping any existing values.
+func (bi *BagInfo) Add(label, value string) {
+	*bi = append(*bi, Tag{Label: label, Value: value})
+}
+
+// PayloadOxum is the octet count and stream count of a bag payload, recorded
+// in bag-info.txt as "Payload-Oxum: <bytes>.<files>".
+type PayloadOxum struct {
+	Bytes int64
+	Files int64
+}
+
+// ParsePayloadOxum parses a Payload-Oxum value such as "1024.3".
+func ParsePayloadOxum(s string) (PayloadOxum, error) {
+	b, f, ok := strings.Cut(s, ".")
+	if !ok {
+		return PayloadOxum{}, fmt.Errorf("malformed Payload-Oxum value: %q", s)
+	}
+
+	bytes, err := strconv.ParseInt(b, 10, 64)
+	if err != nil || bytes < 0 {
+		return PayloadOxum{}, fmt.Errorf("malformed Payload-Oxum value: %q", s)
+	}
+	files, err := strconv.ParseInt(f, 10, 64)
+	if err != nil || files < 0 {
+		return PayloadOxum{}, fmt.Errorf("malformed Payload-Oxum value: %q", s)
+	}
+
+	return PayloadOxum{Bytes: bytes, Files: files}, nil
+}
+
+func (o PayloadOxum) String() string {
+	return fmt.Sprintf("%d.%d", o.Bytes, o.Files)
+}
@@ -0,0 +1,39 @@
+package bagit_test
+
+import (
+	"testing"
+
+	"github.com/artefactual-labs/bagit-gython"
+	"gotest.tools/v3/assert"
+)
+
+func TestParsePayloadOxum(t *testing.T) {
+	t.Parallel()
+
+	oxum, err := bagit.ParsePayloadOxum("1024.3")
+	assert.NilError(t, err)
+	assert.Equal(t, oxum, bagit.PayloadOxum{Bytes: 1024, Files: 3})
+	assert.Equal(t, oxum.String(), "1024.3")
+
+	for _, value := range []string{"", "1024", "a.3", "1024.b", "-1.3"} {
+		_, err := bagit.ParsePayloadOxum(value)
+		assert.ErrorContains(t, err, "malformed Payload-Oxum value")
+	}
+}
+
+func TestBagInfo(t *testing.T) {
+	t.Parallel()
+
+	bi := bagit.BagInfo{{Label: "Contact-Name", Value: "Jane"}}
+	bi.Add("Source-Organization", "Artefactual")
+	bi.Add("Contact-Name", "John")
+
+	value, ok := bi.Get("Contact-Name")
+	assert.Assert(t, ok)
+	assert.Equal(t, value, "Jane")
+	assert.DeepEqual(t, bi.Values("Contact-Name"), []string{"Jane", "John"})
+
+	_, ok = bi.Get("Bagging-Date")
+	assert.Assert(t, !ok)
+	assert.Assert(t, bi.Values("Bagging-Date") == nil)
+}
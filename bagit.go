@@ -1,6 +1,7 @@
 package bagit
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -26,6 +27,11 @@ var (
 	// integrity of the shared resources.
 	ErrBusy = errors.New("runner is busy")
 
+	// ErrTimeout is returned when a command runs longer than the request
+	// timeout set with WithRequestTimeout. The runner process is terminated
+	// and replaced by the next command.
+	ErrTimeout = errors.New("request timed out")
+
 	// ErrClosed is returned when an operation is attempted on a closed BagIt or
 	// Validator.
 	ErrClosed = errors.New("validator is closed")
@@ -56,13 +62,26 @@ type bagItRuntime struct {
 // extracts necessary libraries. Reuse an instance for serial operations when
 // possible, but do not use the same BagIt concurrently. Use Validator when a
 // shared concurrency-safe validator is needed.
-func NewBagIt() (_ *BagIt, err error) {
+//
+// The options configuring runners apply to the BagIt runner: WithLogger,
+// WithMaxRestarts, WithRequestTimeout, WithTracerProvider and
+// WithExtractionLimits. Options of the Validator pool and runtime, e.g.
+// WithPoolSize or WithCacheDir, are ignored.
+func NewBagIt(opts ...ValidatorOption) (_ *BagIt, err error) {
+	cfg := validatorConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if err := cfg.runner.validate(); err != nil {
+		return nil, err
+	}
+
 	runtime, err := newBagItRuntime(bagItRuntimeConfig{})
 	if err != nil {
 		return nil, err
 	}
 
-	return newBagIt(runtime, true), nil
+	return newBagIt(runtime, true, cfg.runner), nil
 }
 
 func newBagItRuntime(cfg bagItRuntimeConfig) (_ *bagItRuntime, err error) {
@@ -133,30 +152,115 @@ func prepareRuntimeCacheDir(path string) error {
 	return nil
 }
 
-func newBagIt(runtime *bagItRuntime, ownsRuntime bool) *BagIt {
+func newBagIt(runtime *bagItRuntime, ownsRuntime bool, cfg runnerConfig) *BagIt {
 	return &BagIt{
 		runtime:     runtime,
 		ownsRuntime: ownsRuntime,
 		runner: createRunner(
 			runtime.embedPython,
 			filepath.Join(runtime.embedRunner.GetExtractedPath(), "main.py"),
+			cfg,
 		),
 	}
 }
 
+// ValidateOption configures a single validation.
+type ValidateOption func(*validateRequest)
+
+// WithFast limits validation to the bag structure and its Payload-Oxum, i.e.
+// the number of payload files and their total size, without computing
+// checksums. Validation fails if bag-info.txt has no Payload-Oxum. WithFast
+// takes precedence over WithCompletenessOnly.
+func WithFast() ValidateOption {
+	return func(req *validateRequest) {
+		req.Fast = true
+	}
+}
+
+// WithCompletenessOnly limits validation to the bag structure, Payload-Oxum
+// and completeness, i.e. every file listed in the manifests exists and every
+// payload file is listed, without computing checksums.
+func WithCompletenessOnly() ValidateOption {
+	return func(req *validateRequest) {
+		req.CompletenessOnly = true
+	}
+}
+
+// WithProcesses sets the number of processes used to compute checksums.
+//
+// By default, bagit-python uses one process per CPU. Values lower than one
+// keep the default.
+func WithProcesses(n int) ValidateOption {
+	return func(req *validateRequest) {
+		req.Processes = max(n, 0)
+	}
+}
+
 type validateRequest struct {
-	Path string `json:"path"`
+	Path             string `json:"path"`
+	Processes        int    `json:"processes,omitempty"`
+	Fast             bool   `json:"fast,omitempty"`
+	CompletenessOnly bool   `json:"completeness_only,omitempty"`
+	Progress         bool   `json:"progress,omitempty"`
+	BytesHashed      bool   `json:"bytes_hashed,omitempty"`
+
+	progress    ProgressFunc
+	bytesHashed func(n int64) // See withBytesHashed.
 }
 
 type validateResponse struct {
-	Valid bool   `json:"valid"`
-	Err   string `json:"err"`
+	errorResponse
+	Valid       bool  `json:"valid"`
+	BytesHashed int64 `json:"bytes_hashed"`
 }
 
-func (b *BagIt) Validate(path string) error {
-	blob, err := b.send("validate", &validateRequest{
-		Path: path,
-	})
+// Validate validates the bag at path. When the bag is invalid, the returned
+// error is a *ValidationError wrapping ErrInvalid. A path that does not exist
+// is reported with a *BagError matching fs.ErrNotExist instead. Failures of
+// the embedded runner are reported with a *RunnerError.
+//
+// Path may also be a serialized bag, i.e. a zip, tar, or gzip or Zstandard
+// compressed tar archive detected by content, holding the bag as its single
+// top-level directory. It is extracted into a temporary directory removed once
+// validation completes.
+//
+// By default, Validate performs a full validation, including checksums. Use
+// WithFast or WithCompletenessOnly for cheaper checks.
+func (b *BagIt) Validate(path string, opts ...ValidateOption) error {
+	return b.ValidateContext(context.Background(), path, opts...)
+}
+
+// ValidateContext validates the bag at path, see Validate.
+//
+// If ctx is done before the validation completes, the runner process and its
+// workers are terminated and ValidateContext returns ctx.Err(). The runner is
+// restarted by the next command.
+func (b *BagIt) ValidateContext(ctx context.Context, path string, opts ...ValidateOption) error {
+	bag, err := b.extract(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer bag.cleanup()
+
+	return b.validate(ctx, path, bag.path, opts...)
+}
+
+// validate validates the bag at path, found in dir once extracted, see
+// extractPath.
+func (b *BagIt) validate(ctx context.Context, path, dir string, opts ...ValidateOption) error {
+	req := &validateRequest{Path: dir}
+	for _, opt := range opts {
+		opt(req)
+	}
+	// Only count the bytes hashed from progress events if the caller asked
+	// for them, the runner otherwise validates without reporting progress.
+	if req.Progress {
+		req.countProgressBytes()
+	} else {
+		req.BytesHashed = req.bytesHashed != nil
+	}
+
+	blob, err := b.send(ctx, "validate", req, req.progress)
 	if err != nil {
 		return err
 	}
@@ -164,53 +268,324 @@ func (b *BagIt) Validate(path string) error {
 	r := validateResponse{}
 	err = json.Unmarshal(blob, &r)
 	if err != nil {
-		return fmt.Errorf("decode response: %v", err)
+		return &RunnerError{Message: "decode response", Err: err}
 	}
-	if r.Err != "" {
-		return fmt.Errorf("%w: %s", ErrInvalid, r.Err)
+	if err := r.validationError(path); err != nil {
+		return err
 	}
 	if !r.Valid {
-		return ErrInvalid
+		return &ValidationError{}
+	}
+	if req.BytesHashed && r.BytesHashed > 0 {
+		req.bytesHashed(r.BytesHashed)
 	}
 
 	return nil
 }
 
+// MakeOptions configures the creation of a bag. The zero value uses the
+// bagit-python defaults.
+type MakeOptions struct {
+	// BagInfo lists the tags written to bag-info.txt. bagit-python sorts tags
+	// by label and keeps the order of repeated labels. Bagging-Date and
+	// Bag-Software-Agent are generated unless given, Payload-Oxum is always
+	// generated.
+	BagInfo BagInfo
+
+	// Checksums lists the manifest algorithms, SHA256 and SHA512 by default.
+	Checksums []Algorithm
+
+	// Processes is the number of processes used to compute checksums, one by
+	// default.
+	Processes int
+
+	// Encoding is the character encoding of the manifest files, UTF-8 by
+	// default.
+	Encoding string
+
+	// Progress, if set, is called while the payload checksums are computed,
+	// see WithProgress.
+	Progress ProgressFunc
+}
+
+// MakeResult describes a bag created by Make.
+type MakeResult struct {
+	// Version is the BagIt version of the bag, e.g. "1.0".
+	Version string
+
+	// PayloadOxum is the payload size and file count recorded in
+	// bag-info.txt.
+	PayloadOxum PayloadOxum
+}
+
 type makeRequest struct {
-	Path string `json:"path"`
+	Path      string      `json:"path"`
+	BagInfo   BagInfo     `json:"bag_info,omitempty"`
+	Checksums []Algorithm `json:"checksums,omitempty"`
+	Processes int         `json:"processes,omitempty"`
+	Encoding  string      `json:"encoding,omitempty"`
+	Progress  bool        `json:"progress,omitempty"`
 }
 
 type makeResponse struct {
-	Version string `json:"version"`
-	Err     string `json:"err"`
+	errorResponse
+	Version     string `json:"version"`
+	PayloadOxum string `json:"payload_oxum"`
+}
+
+// Make converts the directory at path into a bag in place. Failures reported
+// by bagit-python are returned as a wrapped *BagError.
+func (b *BagIt) Make(path string, opts MakeOptions) (MakeResult, error) {
+	return b.MakeContext(context.Background(), path, opts)
 }
 
-func (b *BagIt) Make(path string) error {
-	blob, err := b.send("make", &makeRequest{
-		Path: path,
-	})
+// MakeContext converts the directory at path into a bag in place, see Make.
+//
+// If ctx is done before the bag is created, the runner process and its workers
+// are terminated and MakeContext returns ctx.Err(). The directory may be left
+// partially bagged.
+func (b *BagIt) MakeContext(ctx context.Context, path string, opts MakeOptions) (MakeResult, error) {
+	blob, err := b.send(ctx, "make", &makeRequest{
+		Path:      path,
+		BagInfo:   opts.BagInfo,
+		Checksums: opts.Checksums,
+		Processes: max(opts.Processes, 0),
+		Encoding:  opts.Encoding,
+		Progress:  opts.Progress != nil,
+	}, opts.Progress)
 	if err != nil {
-		return err
+		return MakeResult{}, err
 	}
 
 	r := makeResponse{}
 	err = json.Unmarshal(blob, &r)
 	if err != nil {
-		return fmt.Errorf("decode response: %v", err)
+		return MakeResult{}, &RunnerError{Message: "decode response", Err: err}
+	}
+	if err := r.asError(path); err != nil {
+		return MakeResult{}, fmt.Errorf("make: %w", err)
+	}
+
+	oxum, err := ParsePayloadOxum(r.PayloadOxum)
+	if err != nil {
+		return MakeResult{}, &RunnerError{Message: "decode response", Err: err}
+	}
+
+	return MakeResult{Version: r.Version, PayloadOxum: oxum}, nil
+}
+
+// UpdateOptions describes changes to an existing bag. Tags are removed first,
+// then set, then added.
+type UpdateOptions struct {
+	// Set replaces every value of the given labels in bag-info.txt. Repeat a
+	// label to set multiple values.
+	Set BagInfo
+
+	// Add appends values to bag-info.txt, keeping existing values.
+	Add BagInfo
+
+	// Remove deletes every value of the given labels from bag-info.txt.
+	Remove []string
+
+	// Manifests regenerates the payload manifests and the Payload-Oxum, e.g.
+	// after files were added to, changed in or removed from the payload.
+	// Otherwise, the recorded Payload-Oxum is kept and Update fails with a
+	// *ValidationError if payload files are missing from or not listed in the
+	// manifests, or if their sizes do not match the Payload-Oxum. Checksums
+	// are not computed, use Validate to check them.
+	Manifests bool
+
+	// Processes is the number of processes used to compute checksums, one by
+	// default.
+	Processes int
+
+	// Progress, if set, is called while the payload checksums are computed,
+	// see WithProgress.
+	Progress ProgressFunc
+}
+
+type updateRequest struct {
+	Path      string   `json:"path"`
+	Set       BagInfo  `json:"set,omitempty"`
+	Add       BagInfo  `json:"add,omitempty"`
+	Remove    []string `json:"remove,omitempty"`
+	Manifests bool     `json:"manifests,omitempty"`
+	Processes int      `json:"processes,omitempty"`
+	Progress  bool     `json:"progress,omitempty"`
+}
+
+// Update changes the bag-info.txt tags of the bag at path in place and
+// rewrites its Payload-Oxum and tag manifests, see UpdateOptions. bagit-python
+// sorts the tags of bag-info.txt by label.
+//
+// Failures of the bag reported by bagit-python, e.g. a missing bagit.txt or,
+// unless UpdateOptions.Manifests is set, a payload that does not match the
+// manifests or the Payload-Oxum, are reported with a *ValidationError, as by
+// Validate. A path that does not exist is reported with a wrapped *BagError.
+func (b *BagIt) Update(path string, opts UpdateOptions) error {
+	return b.UpdateContext(context.Background(), path, opts)
+}
+
+// UpdateContext changes the bag at path in place, see Update.
+//
+// If ctx is done before the bag is updated, the runner process and its workers
+// are terminated and UpdateContext returns ctx.Err(). The bag may be left
+// partially updated.
+func (b *BagIt) UpdateContext(ctx context.Context, path string, opts UpdateOptions) error {
+	blob, err := b.send(ctx, "update", &updateRequest{
+		Path:      path,
+		Set:       opts.Set,
+		Add:       opts.Add,
+		Remove:    opts.Remove,
+		Manifests: opts.Manifests,
+		Processes: max(opts.Processes, 0),
+		Progress:  opts.Progress != nil,
+	}, opts.Progress)
+	if err != nil {
+		return err
+	}
+
+	r := errorResponse{}
+	err = json.Unmarshal(blob, &r)
+	if err != nil {
+		return &RunnerError{Message: "decode response", Err: err}
 	}
-	if r.Err != "" {
-		return fmt.Errorf("make: %s", r.Err)
+	if err := r.validationError(path); err != nil {
+		var verr *ValidationError
+		if errors.As(err, &verr) {
+			return err
+		}
+		return fmt.Errorf("update: %w", err)
 	}
 
 	return nil
 }
 
-func (b *BagIt) send(name string, args any) ([]byte, error) {
+// Inspect reads the bag at path without validating it. Failures reported by
+// bagit-python, e.g. a missing bagit.txt, are returned as a wrapped *BagError.
+func (b *BagIt) Inspect(path string) (*Bag, error) {
+	return b.InspectContext(context.Background(), path)
+}
+
+// InspectContext reads the bag at path, see Inspect.
+//
+// If ctx is done before the bag is read, the runner process is terminated and
+// InspectContext returns ctx.Err().
+func (b *BagIt) InspectContext(ctx context.Context, path string) (*Bag, error) {
+	blob, err := b.send(ctx, "inspect", &inspectRequest{Path: path}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	r := inspectResponse{}
+	err = json.Unmarshal(blob, &r)
+	if err != nil {
+		return nil, &RunnerError{Message: "decode response", Err: err}
+	}
+	if err := r.asError(path); err != nil {
+		return nil, fmt.Errorf("inspect: %w", err)
+	}
+
+	return r.bag(), nil
+}
+
+// ValidateProfile checks that the bag at path conforms to profile. When it
+// does not, the returned error is a *ProfileError wrapping ErrInvalid that
+// lists every violation found. ValidateProfile does not validate the bag
+// itself, use Validate for that.
+//
+// Path may also be a serialized bag, see Validate, which is checked against
+// the Serialization and Accept-Serialization rules of the profile.
+func (b *BagIt) ValidateProfile(path string, profile *Profile) error {
+	return b.ValidateProfileContext(context.Background(), path, profile)
+}
+
+// ValidateProfileContext checks the bag at path against profile, see
+// ValidateProfile.
+//
+// If ctx is done before the check completes, the runner process is terminated
+// and ValidateProfileContext returns ctx.Err().
+func (b *BagIt) ValidateProfileContext(ctx context.Context, path string, profile *Profile) error {
+	if profile == nil {
+		return errors.New("validate profile: profile is nil")
+	}
+
+	bag, err := b.extract(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer bag.cleanup()
+
+	return b.validateProfile(ctx, bag, profile)
+}
+
+// validateProfile checks the bag, once extracted, against profile, see
+// extractPath.
+func (b *BagIt) validateProfile(ctx context.Context, bag *extractedBag, profile *Profile) error {
+	c := &profileCheck{profile: profile, dir: bag.path, format: bag.format}
+	inspected, err := b.InspectContext(ctx, c.dir)
+	if err != nil {
+		return err
+	}
+	c.bag = inspected
+
+	if err := c.run(); err != nil {
+		return fmt.Errorf("validate profile: %v", err)
+	}
+	if len(c.violations) > 0 {
+		return &ProfileError{Profile: profile.Info.Identifier, Violations: c.violations}
+	}
+
+	return nil
+}
+
+type pingResponse struct {
+	errorResponse
+	Pong bool `json:"pong"`
+}
+
+// ping checks that the runner answers commands, starting its process if it is
+// not running.
+func (b *BagIt) ping(ctx context.Context) error {
+	blob, err := b.send(ctx, "ping", struct{}{}, nil)
+	if err != nil {
+		return err
+	}
+
+	r := pingResponse{}
+	err = json.Unmarshal(blob, &r)
+	if err != nil {
+		return &RunnerError{Message: "decode response", Err: err}
+	}
+	if err := r.asError(""); err != nil {
+		return err
+	}
+	if !r.Pong {
+		return &RunnerError{Message: "unexpected ping response"}
+	}
+
+	return nil
+}
+
+// extract extracts the serialized bag at path with the runner limits, see
+// extractPath. It returns ErrClosed once the BagIt has been cleaned up.
+func (b *BagIt) extract(ctx context.Context, path string) (*extractedBag, error) {
 	if b == nil || b.runner == nil {
 		return nil, ErrClosed
 	}
 
-	return b.runner.send(name, args)
+	return extractPath(ctx, path, b.runner.cfg.extractLimits)
+}
+
+func (b *BagIt) send(ctx context.Context, name string, args any, progress ProgressFunc) ([]byte, error) {
+	if b == nil || b.runner == nil {
+		return nil, ErrClosed
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return b.runner.send(ctx, name, args, progress)
 }
 
 func (b *BagIt) Cleanup() error {
@@ -2,6 +2,11 @@ package bagit_test
 
 import (
 	"errors"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/artefactual-labs/bagit-gython"
@@ -89,9 +94,38 @@ func TestValidateBag(t *testing.T) {
 
 		b := setUp(t)
 
-		err := b.Validate("/tmp/691b8e7f-e6b7-41dd-bc47-868e2ff69333")
-		assert.Error(t, err, "invalid: Expected bagit.txt does not exist: /tmp/691b8e7f-e6b7-41dd-bc47-868e2ff69333/bagit.txt")
+		path := t.TempDir()
+		err := b.Validate(path)
+		assert.Error(t, err, "invalid: Expected bagit.txt does not exist: "+filepath.Join(path, "bagit.txt"))
 		assert.Assert(t, errors.Is(err, bagit.ErrInvalid))
+		assert.Assert(t, !errors.Is(err, iofs.ErrNotExist))
+
+		var berr *bagit.BagError
+		assert.Assert(t, errors.As(err, &berr))
+		assert.Equal(t, berr.Type, "BagError")
+
+		var serr *bagit.StructureError
+		assert.Assert(t, errors.As(err, &serr))
+
+		var rerr *bagit.RunnerError
+		assert.Assert(t, !errors.As(err, &rerr))
+	})
+
+	t.Run("Reports missing bags", func(t *testing.T) {
+		t.Parallel()
+
+		b := setUp(t)
+
+		err := b.Validate("/tmp/691b8e7f-e6b7-41dd-bc47-868e2ff69333")
+		assert.Assert(t, !errors.Is(err, bagit.ErrInvalid))
+		assert.Assert(t, errors.Is(err, iofs.ErrNotExist))
+
+		var berr *bagit.BagError
+		assert.Assert(t, errors.As(err, &berr))
+		assert.Equal(t, berr.Message, "Expected bagit.txt does not exist: /tmp/691b8e7f-e6b7-41dd-bc47-868e2ff69333/bagit.txt")
+
+		var verr *bagit.ValidationError
+		assert.Assert(t, !errors.As(err, &verr))
 	})
 
 	t.Run("Validates bag", func(t *testing.T) {
@@ -102,6 +136,116 @@ func TestValidateBag(t *testing.T) {
 		err := b.Validate("internal/testdata/valid-bag")
 		assert.NilError(t, err)
 	})
+
+	t.Run("Reports checksum mismatches", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := fs.NewDir(t, "", fs.WithFile("test.txt", "abcd"))
+
+		b := setUp(t)
+		_, err := b.Make(tmpDir.Path(), bagit.MakeOptions{})
+		assert.NilError(t, err)
+		assert.NilError(t, os.WriteFile(tmpDir.Join("data", "test.txt"), []byte("abce"), 0o644))
+
+		err = b.Validate(tmpDir.Path())
+		assert.Assert(t, errors.Is(err, bagit.ErrInvalid))
+
+		var verr *bagit.ValidationError
+		assert.Assert(t, errors.As(err, &verr))
+		assert.Equal(t, len(verr.Report.Details), 2)
+
+		details := verr.Report.Details
+		slices.SortFunc(details, func(a, b bagit.ValidationDetail) int {
+			return strings.Compare(a.Algorithm, b.Algorithm)
+		})
+		assert.Equal(t, details[0].Type, bagit.ChecksumMismatch)
+		assert.Equal(t, details[0].Path, "data/test.txt")
+		assert.Equal(t, details[0].Algorithm, "sha256")
+		assert.Equal(t, details[0].Expected, "88d4266fd4e6338d13b845fcf289579d209c897823b9217da3e161936f031589")
+		assert.Equal(t, details[0].Found, "84e73dc50f2be9000ab2a87f8026c1f45e1fec954af502e9904031645b190d4f")
+		assert.Equal(t, details[1].Type, bagit.ChecksumMismatch)
+		assert.Equal(t, details[1].Algorithm, "sha512")
+
+		var cerr *bagit.ChecksumMismatchError
+		assert.Assert(t, errors.As(err, &cerr))
+		assert.Equal(t, cerr.Path, "data/test.txt")
+
+		var serr *bagit.StructureError
+		assert.Assert(t, !errors.As(err, &serr))
+	})
+
+	t.Run("Validates with options", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := fs.NewDir(t, "", fs.WithFile("test.txt", "abcd"))
+
+		b := setUp(t)
+		_, err := b.Make(tmpDir.Path(), bagit.MakeOptions{})
+		assert.NilError(t, err)
+		assert.NilError(t, os.WriteFile(tmpDir.Join("data", "test.txt"), []byte("abce"), 0o644))
+
+		assert.NilError(t, b.Validate(tmpDir.Path(), bagit.WithFast()))
+		assert.NilError(t, b.Validate(tmpDir.Path(), bagit.WithCompletenessOnly()))
+
+		err = b.Validate(tmpDir.Path(), bagit.WithProcesses(1))
+		var cerr *bagit.ChecksumMismatchError
+		assert.Assert(t, errors.As(err, &cerr))
+
+		assert.NilError(t, os.WriteFile(tmpDir.Join("data", "extra.txt"), []byte("efgh"), 0o644))
+		err = b.Validate(tmpDir.Path(), bagit.WithFast())
+		assert.ErrorContains(t, err, "Payload-Oxum validation failed")
+	})
+
+	t.Run("Reports unexpected files", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := fs.NewDir(t, "", fs.WithFile("test.txt", "abcd"))
+
+		b := setUp(t)
+		_, err := b.Make(tmpDir.Path(), bagit.MakeOptions{})
+		assert.NilError(t, err)
+		assert.NilError(t, os.WriteFile(tmpDir.Join("bag-info.txt"), []byte("Bagging-Date: 2024-04-19\n"), 0o644))
+		assert.NilError(t, os.WriteFile(tmpDir.Join("data", "extra.txt"), []byte("efgh"), 0o644))
+
+		err = b.Validate(tmpDir.Path())
+
+		var verr *bagit.ValidationError
+		assert.Assert(t, errors.As(err, &verr))
+		assert.Equal(t, verr.Report.Message, "Bag is incomplete: data/extra.txt exists on filesystem but is not in the manifest")
+		assert.DeepEqual(t, verr.Report.Details, []bagit.ValidationDetail{
+			{
+				Type:    bagit.UnexpectedFile,
+				Path:    "data/extra.txt",
+				Message: "data/extra.txt exists on filesystem but is not in the manifest",
+			},
+		})
+
+		var uerr *bagit.UnexpectedFileError
+		assert.Assert(t, errors.As(err, &uerr))
+		assert.Error(t, uerr, "data/extra.txt exists on filesystem but is not in the manifest")
+	})
+}
+
+func TestNewValidationError(t *testing.T) {
+	t.Parallel()
+
+	err := bagit.NewValidationError(bagit.ValidationReport{
+		Message: "Bag validation failed",
+		Details: []bagit.ValidationDetail{{Type: bagit.FileMissing, Path: "data/a.txt"}},
+	})
+	assert.ErrorIs(t, err, bagit.ErrInvalid)
+	var ferr *bagit.FileMissingError
+	assert.Assert(t, errors.As(err, &ferr))
+	assert.Equal(t, ferr.Path, "data/a.txt")
+
+	err = bagit.NewValidationError(bagit.ValidationReport{Message: "Payload-Oxum validation failed"})
+	var serr *bagit.StructureError
+	assert.Assert(t, errors.As(err, &serr))
+
+	// A ValidationError without errors only wraps ErrInvalid.
+	errs := (&bagit.ValidationError{}).Unwrap()
+	assert.Equal(t, len(errs), 1)
+	assert.Equal(t, errs[0], bagit.ErrInvalid)
 }
 
 func TestMakeBag(t *testing.T) {
@@ -114,8 +258,12 @@ func TestMakeBag(t *testing.T) {
 
 		b := setUp(t)
 
-		err := b.Make(tmpDir.Path())
+		res, err := b.Make(tmpDir.Path(), bagit.MakeOptions{})
 		assert.NilError(t, err)
+		assert.DeepEqual(t, res, bagit.MakeResult{
+			Version:     "1.0",
+			PayloadOxum: bagit.PayloadOxum{Bytes: 4, Files: 1},
+		})
 
 		assert.Assert(t, fs.Equal(tmpDir.Path(), fs.Expected(t,
 			fs.WithDir("data", fs.WithFile("test.txt", "abcd"), fs.MatchAnyFileMode),
@@ -130,14 +278,324 @@ Tag-File-Character-Encoding: UTF-8
 		)))
 	})
 
+	t.Run("Creates bag with options", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := fs.NewDir(t, "", fs.WithFile("test.txt", "abcd"), fs.WithFile("other.txt", "ef"))
+
+		b := setUp(t)
+
+		res, err := b.Make(tmpDir.Path(), bagit.MakeOptions{
+			BagInfo: bagit.BagInfo{
+				{Label: "Source-Organization", Value: "Artefactual"},
+				{Label: "Contact-Name", Value: "Jane"},
+				{Label: "Contact-Name", Value: "John"},
+				{Label: "Bagging-Date", Value: "2024-04-19"},
+			},
+			Checksums: []bagit.Algorithm{bagit.MD5},
+			Processes: 2,
+			Encoding:  "utf-8",
+		})
+		assert.NilError(t, err)
+		assert.Equal(t, res.PayloadOxum, bagit.PayloadOxum{Bytes: 6, Files: 2})
+
+		assert.Assert(t, fs.Equal(tmpDir.Path(), fs.Expected(t,
+			fs.WithDir("data",
+				fs.WithFile("test.txt", "abcd"),
+				fs.WithFile("other.txt", "ef"),
+				fs.MatchAnyFileMode,
+			),
+			fs.WithFile("bagit.txt", "", fs.MatchAnyFileContent, fs.MatchAnyFileMode),
+			fs.WithFile("bag-info.txt", "", fs.MatchAnyFileContent, fs.MatchAnyFileMode),
+			fs.WithFile("manifest-md5.txt", `feb78cc258bdc76867354f01c22dbe43  data/other.txt
+e2fc714c4727ee9395f324cd2e7f331f  data/test.txt
+`, fs.MatchAnyFileMode),
+			fs.WithFile("tagmanifest-md5.txt", "", fs.MatchAnyFileContent, fs.MatchAnyFileMode),
+		)))
+
+		bagInfo, err := os.ReadFile(tmpDir.Join("bag-info.txt"))
+		assert.NilError(t, err)
+		assert.Assert(t, strings.Contains(string(bagInfo), `Bagging-Date: 2024-04-19
+Contact-Name: Jane
+Contact-Name: John
+Payload-Oxum: 6.2
+Source-Organization: Artefactual
+`))
+
+		assert.NilError(t, b.Validate(tmpDir.Path()))
+	})
+
+	t.Run("Rejects unsupported checksum algorithms", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := fs.NewDir(t, "", fs.WithFile("test.txt", "abcd"))
+
+		b := setUp(t)
+
+		_, err := b.Make(tmpDir.Path(), bagit.MakeOptions{
+			Checksums: []bagit.Algorithm{"crc32"},
+		})
+		assert.ErrorContains(t, err, "Unsupported checksum algorithm: crc32")
+
+		var berr *bagit.BagError
+		assert.Assert(t, errors.As(err, &berr))
+	})
+
 	t.Run("Reports creation failures", func(t *testing.T) {
 		t.Parallel()
 
 		b := setUp(t)
 
-		err := b.Make("non-existent-dir")
+		_, err := b.Make("non-existent-dir", bagit.MakeOptions{})
 		assert.ErrorContains(t, err, "does not exist")
+		assert.Assert(t, errors.Is(err, iofs.ErrNotExist))
+
+		var berr *bagit.BagError
+		assert.Assert(t, errors.As(err, &berr))
+		assert.Assert(t, !errors.Is(err, bagit.ErrInvalid))
+	})
+}
+
+func TestInspectBag(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Reads bag", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := fs.NewDir(t, "", fs.WithFile("test.txt", "abcd"), fs.WithFile("other.txt", "ef"))
+
+		b := setUp(t)
+
+		_, err := b.Make(tmpDir.Path(), bagit.MakeOptions{
+			BagInfo: bagit.BagInfo{
+				{Label: "Contact-Name", Value: "Ada"},
+				{Label: "Bagging-Date", Value: "2024-04-19"},
+				{Label: "Contact-Name", Value: "Grace"},
+			},
+			Checksums: []bagit.Algorithm{bagit.MD5},
+		})
+		assert.NilError(t, err)
+
+		err = os.WriteFile(tmpDir.Join("fetch.txt"), []byte("https://example.com/remote.txt - data/remote.txt\n"), 0o600)
+		assert.NilError(t, err)
+		tagManifest, err := os.OpenFile(tmpDir.Join("tagmanifest-md5.txt"), os.O_APPEND|os.O_WRONLY, 0)
+		assert.NilError(t, err)
+		_, err = tagManifest.WriteString("d41d8cd98f00b204e9800998ecf8427e  custom-tags.txt\n")
+		assert.NilError(t, err)
+		assert.NilError(t, tagManifest.Close())
+
+		bag, err := b.Inspect(tmpDir.Path())
+		assert.NilError(t, err)
+
+		assert.Equal(t, bag.Path, tmpDir.Path())
+		assert.Equal(t, bag.Version, "1.0")
+		assert.Equal(t, bag.Encoding, "UTF-8")
+		assert.DeepEqual(t, bag.BagInfo.Values("Contact-Name"), []string{"Ada", "Grace"})
+		assert.DeepEqual(t, bag.Algorithms, []bagit.Algorithm{bagit.MD5})
+		assert.DeepEqual(t, bag.PayloadOxum, &bagit.PayloadOxum{Bytes: 6, Files: 2})
+		assert.DeepEqual(t, bag.Manifests, map[bagit.Algorithm][]bagit.ManifestEntry{
+			bagit.MD5: {
+				{Path: "data/other.txt", Checksum: "feb78cc258bdc76867354f01c22dbe43"},
+				{Path: "data/test.txt", Checksum: "e2fc714c4727ee9395f324cd2e7f331f"},
+			},
+		})
+		assert.DeepEqual(t, paths(bag.TagManifests[bagit.MD5]), []string{
+			"bag-info.txt",
+			"bagit.txt",
+			"custom-tags.txt",
+			"manifest-md5.txt",
+		})
+		assert.DeepEqual(t, bag.Fetch, []bagit.FetchEntry{
+			{URL: "https://example.com/remote.txt", Size: -1, Path: "data/remote.txt"},
+		})
+		assert.DeepEqual(t, bag.MissingOptionalTagFiles, []string{"custom-tags.txt"})
+	})
+
+	t.Run("Reports missing bags", func(t *testing.T) {
+		t.Parallel()
+
+		b := setUp(t)
+
+		_, err := b.Inspect("/tmp/691b8e7f-e6b7-41dd-bc47-868e2ff69333")
+		assert.ErrorIs(t, err, iofs.ErrNotExist)
+		assert.Assert(t, !errors.Is(err, bagit.ErrInvalid))
+
+		var berr *bagit.BagError
+		assert.Assert(t, errors.As(err, &berr))
+		assert.Equal(t, berr.Message, "Expected bagit.txt does not exist: /tmp/691b8e7f-e6b7-41dd-bc47-868e2ff69333/bagit.txt")
+	})
+}
+
+func paths(entries []bagit.ManifestEntry) []string {
+	var paths []string
+	for _, entry := range entries {
+		paths = append(paths, entry.Path)
+	}
+
+	return paths
+}
+
+func TestUpdateBag(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Updates tags", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := fs.NewDir(t, "", fs.WithFile("test.txt", "abcd"))
+
+		b := setUp(t)
+
+		_, err := b.Make(tmpDir.Path(), bagit.MakeOptions{
+			BagInfo: bagit.BagInfo{
+				{Label: "Contact-Name", Value: "Ada"},
+				{Label: "Source-Organization", Value: "Artefactual"},
+				{Label: "External-Identifier", Value: "a"},
+			},
+		})
+		assert.NilError(t, err)
+
+		err = b.Update(tmpDir.Path(), bagit.UpdateOptions{
+			Set:    bagit.BagInfo{{Label: "Contact-Name", Value: "Grace"}},
+			Add:    bagit.BagInfo{{Label: "External-Identifier", Value: "b"}},
+			Remove: []string{"Source-Organization"},
+		})
+		assert.NilError(t, err)
+
+		bag, err := b.Inspect(tmpDir.Path())
+		assert.NilError(t, err)
+		assert.DeepEqual(t, bag.BagInfo.Values("Contact-Name"), []string{"Grace"})
+		assert.DeepEqual(t, bag.BagInfo.Values("External-Identifier"), []string{"a", "b"})
+		assert.DeepEqual(t, bag.BagInfo.Values("Source-Organization"), []string(nil))
+		assert.DeepEqual(t, bag.PayloadOxum, &bagit.PayloadOxum{Bytes: 4, Files: 1})
+
+		assert.NilError(t, b.Validate(tmpDir.Path()))
 	})
+
+	t.Run("Regenerates manifests", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := fs.NewDir(t, "", fs.WithFile("test.txt", "abcd"))
+
+		b := setUp(t)
+
+		_, err := b.Make(tmpDir.Path(), bagit.MakeOptions{})
+		assert.NilError(t, err)
+		err = os.WriteFile(tmpDir.Join("data", "new.txt"), []byte("ef"), 0o600)
+		assert.NilError(t, err)
+
+		err = b.Update(tmpDir.Path(), bagit.UpdateOptions{})
+		assert.ErrorIs(t, err, bagit.ErrInvalid)
+		var uerr *bagit.UnexpectedFileError
+		assert.Assert(t, errors.As(err, &uerr))
+		assert.Equal(t, uerr.Path, "data/new.txt")
+
+		var phases []bagit.Phase
+		err = b.Update(tmpDir.Path(), bagit.UpdateOptions{
+			Manifests: true,
+			Progress: func(p bagit.Progress) {
+				phases = append(phases, p.Phase)
+			},
+		})
+		assert.NilError(t, err)
+		assert.Assert(t, slices.Contains(phases, bagit.PhaseManifests))
+
+		bag, err := b.Inspect(tmpDir.Path())
+		assert.NilError(t, err)
+		assert.DeepEqual(t, bag.PayloadOxum, &bagit.PayloadOxum{Bytes: 6, Files: 2})
+
+		assert.NilError(t, b.Validate(tmpDir.Path()))
+	})
+
+	t.Run("Keeps the Payload-Oxum", func(t *testing.T) {
+		t.Parallel()
+
+		tmpDir := fs.NewDir(t, "", fs.WithFile("test.txt", "abcd"))
+
+		b := setUp(t)
+
+		_, err := b.Make(tmpDir.Path(), bagit.MakeOptions{})
+		assert.NilError(t, err)
+		err = os.WriteFile(tmpDir.Join("data", "test.txt"), []byte("abcdef"), 0o600)
+		assert.NilError(t, err)
+
+		err = b.Update(tmpDir.Path(), bagit.UpdateOptions{
+			Set: bagit.BagInfo{{Label: "Contact-Name", Value: "Grace"}},
+		})
+		assert.ErrorIs(t, err, bagit.ErrInvalid)
+		assert.ErrorContains(t, err, "Payload-Oxum validation failed")
+
+		bag, err := b.Inspect(tmpDir.Path())
+		assert.NilError(t, err)
+		assert.DeepEqual(t, bag.PayloadOxum, &bagit.PayloadOxum{Bytes: 4, Files: 1})
+		assert.DeepEqual(t, bag.BagInfo.Values("Contact-Name"), []string(nil))
+	})
+
+	t.Run("Reports missing bags", func(t *testing.T) {
+		t.Parallel()
+
+		b := setUp(t)
+
+		err := b.Update("/tmp/691b8e7f-e6b7-41dd-bc47-868e2ff69333", bagit.UpdateOptions{})
+		assert.ErrorIs(t, err, iofs.ErrNotExist)
+		assert.Assert(t, !errors.Is(err, bagit.ErrInvalid))
+
+		// Like Validate, a directory that is not a bag is invalid.
+		err = b.Update(t.TempDir(), bagit.UpdateOptions{})
+		assert.ErrorIs(t, err, bagit.ErrInvalid)
+		assert.ErrorContains(t, err, "Expected bagit.txt does not exist")
+	})
+}
+
+func TestProgress(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := fs.NewDir(t, "", fs.WithFile("test.txt", "abcd"), fs.WithFile("other.txt", "ef"))
+
+	b := setUp(t)
+
+	var events []bagit.Progress
+	_, err := b.Make(tmpDir.Path(), bagit.MakeOptions{
+		Processes: 2,
+		Progress: func(p bagit.Progress) {
+			events = append(events, p)
+		},
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, events[0], bagit.Progress{Phase: bagit.PhaseManifests, FilesTotal: 2, BytesTotal: 6})
+	last := events[len(events)-1]
+	assert.Equal(t, last.FilesDone, int64(2))
+	assert.Equal(t, last.BytesDone, int64(6))
+	assert.Assert(t, strings.HasPrefix(last.Path, "data/"))
+
+	for _, processes := range []int{1, 2} {
+		var phases []bagit.Phase
+		var last bagit.Progress
+		err := b.Validate(tmpDir.Path(), bagit.WithProcesses(processes), bagit.WithProgress(func(p bagit.Progress) {
+			if len(phases) == 0 || phases[len(phases)-1] != p.Phase {
+				phases = append(phases, p.Phase)
+			}
+			last = p
+		}))
+		assert.NilError(t, err)
+		assert.DeepEqual(t, phases, []bagit.Phase{
+			bagit.PhaseStructure,
+			bagit.PhaseOxum,
+			bagit.PhaseCompleteness,
+			bagit.PhaseFixity,
+		})
+
+		// Payload files plus the tag files listed in the tag manifests.
+		assert.Equal(t, last.FilesTotal, int64(6))
+		assert.Equal(t, last.FilesDone, last.FilesTotal)
+		assert.Equal(t, last.BytesDone, last.BytesTotal)
+	}
+
+	var phases []bagit.Phase
+	err = b.Validate(tmpDir.Path(), bagit.WithFast(), bagit.WithProgress(func(p bagit.Progress) {
+		phases = append(phases, p.Phase)
+	}))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, phases, []bagit.Phase{bagit.PhaseStructure, bagit.PhaseOxum})
 }
 
 func TestCleanup(t *testing.T) {
@@ -152,6 +610,6 @@ func TestCleanup(t *testing.T) {
 	err = b.Validate("internal/testdata/valid-bag")
 	assert.ErrorIs(t, err, bagit.ErrClosed)
 
-	err = b.Make("internal/testdata/valid-bag")
+	_, err = b.Make("internal/testdata/valid-bag", bagit.MakeOptions{})
 	assert.ErrorIs(t, err, bagit.ErrClosed)
 }
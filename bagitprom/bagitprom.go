@@ -0,0 +1,191 @@
+// Package bagitprom reports the measurements of a bagit.Validator to
+// Prometheus.
+//
+//	m := bagitprom.New(bagitprom.Opts{})
+//	prometheus.MustRegister(m)
+//	validator, err := bagit.NewValidator(bagit.WithMetrics(m))
+package bagitprom
+
+import (
+	"sync"
+	"time"
+
+	"github.com/artefactual-labs/bagit-gython"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Opts configures the metrics created by New.
+type Opts struct {
+	// Namespace prefixes the metric names. Defaults to "bagit".
+	Namespace string
+
+	// ConstLabels are added to every metric, e.g. to tell apart the
+	// validators of a process registered with the same registry.
+	ConstLabels prometheus.Labels
+
+	// Buckets of the duration histograms. Defaults to
+	// prometheus.DefBuckets.
+	Buckets []float64
+}
+
+// Metrics implements bagit.Metrics with Prometheus collectors. Register it
+// with a prometheus.Registerer before using it with bagit.WithMetrics.
+//
+// It exports, with the default namespace:
+//
+//   - bagit_pool_size: maximum number of runners of the pool.
+//   - bagit_runners: runners in the pool.
+//   - bagit_runners_busy: runners executing a command.
+//   - bagit_runners_idle: runners waiting for a command.
+//   - bagit_acquire_wait_seconds: histogram of the wait for a runner.
+//   - bagit_validation_duration_seconds: histogram of the validations by
+//     outcome, i.e. valid, invalid, error, busy or closed.
+//   - bagit_runner_restarts_total: runner processes started again.
+//   - bagit_hashed_bytes_total: bytes of payload and tag files hashed by
+//     validations.
+//   - bagit_bootstrap_duration_seconds: histogram of the extractions of the
+//     embedded runtime.
+type Metrics struct {
+	mu         sync.Mutex
+	nRunners   int
+	nBusy      int
+	pool       prometheus.Gauge
+	runners    prometheus.Gauge
+	busy       prometheus.Gauge
+	idle       prometheus.Gauge
+	wait       prometheus.Histogram
+	validation *prometheus.HistogramVec
+	restarts   prometheus.Counter
+	bytes      prometheus.Counter
+	bootstrap  prometheus.Histogram
+}
+
+var (
+	_ bagit.Metrics        = (*Metrics)(nil)
+	_ prometheus.Collector = (*Metrics)(nil)
+)
+
+// New returns unregistered Metrics.
+func New(opts Opts) *Metrics {
+	if opts.Namespace == "" {
+		opts.Namespace = "bagit"
+	}
+	if opts.Buckets == nil {
+		opts.Buckets = prometheus.DefBuckets
+	}
+
+	gauge := func(name, help string) prometheus.Gauge {
+		return prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   opts.Namespace,
+			Name:        name,
+			Help:        help,
+			ConstLabels: opts.ConstLabels,
+		})
+	}
+	counter := func(name, help string) prometheus.Counter {
+		return prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Name:        name,
+			Help:        help,
+			ConstLabels: opts.ConstLabels,
+		})
+	}
+	histogram := func(name, help string) prometheus.HistogramOpts {
+		return prometheus.HistogramOpts{
+			Namespace:   opts.Namespace,
+			Name:        name,
+			Help:        help,
+			ConstLabels: opts.ConstLabels,
+			Buckets:     opts.Buckets,
+		}
+	}
+
+	m := &Metrics{
+		pool:       gauge("pool_size", "Maximum number of runners of the validator pool."),
+		runners:    gauge("runners", "Number of runners of the validator pool."),
+		busy:       gauge("runners_busy", "Number of runners executing a command."),
+		idle:       gauge("runners_idle", "Number of runners waiting for a command."),
+		wait:       prometheus.NewHistogram(histogram("acquire_wait_seconds", "Time spent waiting for an available runner.")),
+		validation: prometheus.NewHistogramVec(histogram("validation_duration_seconds", "Duration of validations by outcome."), []string{"outcome"}),
+		restarts:   counter("runner_restarts_total", "Number of runner processes started again after a crash, a timeout or a canceled command."),
+		bytes:      counter("hashed_bytes_total", "Number of bytes of payload and tag files hashed by validations."),
+		bootstrap:  prometheus.NewHistogram(histogram("bootstrap_duration_seconds", "Duration of the extractions of the embedded runtime.")),
+	}
+	for _, outcome := range []bagit.ValidationOutcome{
+		bagit.OutcomeValid,
+		bagit.OutcomeInvalid,
+		bagit.OutcomeError,
+		bagit.OutcomeBusy,
+		bagit.OutcomeClosed,
+	} {
+		m.validation.WithLabelValues(string(outcome))
+	}
+
+	return m
+}
+
+func (m *Metrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.pool, m.runners, m.busy, m.idle, m.wait, m.validation, m.restarts, m.bytes, m.bootstrap}
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range m.collectors() {
+		c.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range m.collectors() {
+		c.Collect(ch)
+	}
+}
+
+func (m *Metrics) PoolSize(n int) {
+	m.pool.Set(float64(n))
+}
+
+func (m *Metrics) Runners(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nRunners = n
+	m.runners.Set(float64(n))
+	m.setIdle()
+}
+
+func (m *Metrics) RunnersBusy(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nBusy = n
+	m.busy.Set(float64(n))
+	m.setIdle()
+}
+
+// setIdle sets the idle runners from the runners and the busy ones. Busy counts
+// validations without runners with bagit.BackendNative.
+func (m *Metrics) setIdle() {
+	m.idle.Set(float64(max(m.nRunners-m.nBusy, 0)))
+}
+
+func (m *Metrics) AcquireWait(d time.Duration) {
+	m.wait.Observe(d.Seconds())
+}
+
+func (m *Metrics) Validation(outcome bagit.ValidationOutcome, d time.Duration) {
+	m.validation.WithLabelValues(string(outcome)).Observe(d.Seconds())
+}
+
+func (m *Metrics) RunnerRestarted() {
+	m.restarts.Inc()
+}
+
+func (m *Metrics) BytesHashed(n int64) {
+	m.bytes.Add(float64(n))
+}
+
+func (m *Metrics) Bootstrap(d time.Duration) {
+	m.bootstrap.Observe(d.Seconds())
+}
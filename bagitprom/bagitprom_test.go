@@ -0,0 +1,59 @@
+package bagitprom_test
+
+import (
+	"testing"
+
+	"github.com/artefactual-labs/bagit-gython"
+	"github.com/artefactual-labs/bagit-gython/bagitprom"
+	"github.com/artefactual-labs/bagit-gython/bagittest"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"gotest.tools/v3/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	m := bagitprom.New(bagitprom.Opts{ConstLabels: prometheus.Labels{"service": "ingest"}})
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(m)
+
+	v, err := bagit.NewValidator(bagit.WithBackend(bagit.BackendNative), bagit.WithPoolSize(3), bagit.WithMetrics(m))
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, v.Close())
+	})
+
+	path := bagittest.NewBuilder().WithFile("hello.txt", "hello world").BuildTemp(t)
+	assert.NilError(t, v.Validate(path))
+	assert.NilError(t, v.TryValidate(path))
+
+	families, err := reg.Gather()
+	assert.NilError(t, err)
+	got := map[string]*dto.MetricFamily{}
+	for _, f := range families {
+		got[f.GetName()] = f
+		for _, metric := range f.GetMetric() {
+			assert.Equal(t, metric.GetLabel()[len(metric.GetLabel())-1].GetName(), "service")
+		}
+	}
+
+	assert.Equal(t, got["bagit_pool_size"].GetMetric()[0].GetGauge().GetValue(), 3.0)
+	assert.Equal(t, got["bagit_runners"].GetMetric()[0].GetGauge().GetValue(), 0.0) // No runners with the native backend.
+	assert.Equal(t, got["bagit_runners_busy"].GetMetric()[0].GetGauge().GetValue(), 0.0)
+	assert.Equal(t, got["bagit_runners_idle"].GetMetric()[0].GetGauge().GetValue(), 0.0)
+	assert.Equal(t, got["bagit_acquire_wait_seconds"].GetMetric()[0].GetHistogram().GetSampleCount(), uint64(1))
+	assert.Equal(t, got["bagit_runner_restarts_total"].GetMetric()[0].GetCounter().GetValue(), 0.0)
+	assert.Assert(t, got["bagit_hashed_bytes_total"].GetMetric()[0].GetCounter().GetValue() > 11)
+	assert.Equal(t, got["bagit_bootstrap_duration_seconds"].GetMetric()[0].GetHistogram().GetSampleCount(), uint64(0))
+
+	outcomes := map[string]uint64{}
+	for _, metric := range got["bagit_validation_duration_seconds"].GetMetric() {
+		for _, label := range metric.GetLabel() {
+			if label.GetName() == "outcome" {
+				outcomes[label.GetValue()] = metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	assert.DeepEqual(t, outcomes, map[string]uint64{"valid": 2, "invalid": 0, "error": 0, "busy": 0, "closed": 0})
+}
@@ -0,0 +1,184 @@
+package bagittest
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/artefactual-labs/bagit-gython"
+)
+
+// Builder writes bags to disk without bagit-python. Bags are valid unless
+// corruptions are added with WithCorruptions.
+//
+// The zero value is not usable, call NewBuilder.
+type Builder struct {
+	algorithms  []bagit.Algorithm
+	tags        [][2]string
+	files       map[string]string
+	oxum        bool
+	corruptions []Corruption
+}
+
+// NewBuilder returns a Builder of empty bags with sha256 and sha512 manifests,
+// the defaults of bagit-python, and a Payload-Oxum.
+func NewBuilder() *Builder {
+	return &Builder{
+		algorithms: []bagit.Algorithm{bagit.SHA256, bagit.SHA512},
+		files:      map[string]string{},
+		oxum:       true,
+	}
+}
+
+// WithAlgorithms sets the algorithms of the manifests and tag manifests.
+func (b *Builder) WithAlgorithms(algs ...bagit.Algorithm) *Builder {
+	b.algorithms = slices.Clone(algs)
+	return b
+}
+
+// WithTag adds a tag to bag-info.txt. Tags are written in the order they are
+// added and may be repeated.
+func (b *Builder) WithTag(label, value string) *Builder {
+	b.tags = append(b.tags, [2]string{label, value})
+	return b
+}
+
+// WithFile adds a payload file, name being a slash-separated path relative to
+// the data directory, e.g. "sub/file.txt".
+func (b *Builder) WithFile(name, content string) *Builder {
+	b.files[name] = content
+	return b
+}
+
+// WithoutPayloadOxum omits the Payload-Oxum from bag-info.txt.
+func (b *Builder) WithoutPayloadOxum() *Builder {
+	b.oxum = false
+	return b
+}
+
+// WithCorruptions breaks the bag with the given corruptions once written.
+func (b *Builder) WithCorruptions(cs ...Corruption) *Builder {
+	b.corruptions = append(b.corruptions, cs...)
+	return b
+}
+
+// Build writes the bag to dir, creating it if needed.
+func (b *Builder) Build(dir string) error {
+	var bytes int64
+	names := make([]string, 0, len(b.files))
+	for name, content := range b.files {
+		path := filepath.Join(dir, "data", filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("bagittest: build: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return fmt.Errorf("bagittest: build: %v", err)
+		}
+		names = append(names, name)
+		bytes += int64(len(content))
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "data"), 0o755); err != nil {
+		return fmt.Errorf("bagittest: build: %v", err)
+	}
+	slices.Sort(names)
+
+	var bagInfo strings.Builder
+	for _, tag := range b.tags {
+		fmt.Fprintf(&bagInfo, "%s: %s\n", tag[0], tag[1])
+	}
+	if b.oxum {
+		fmt.Fprintf(&bagInfo, "Payload-Oxum: %d.%d\n", bytes, len(names))
+	}
+	tagFiles := map[string]string{
+		"bagit.txt":    "BagIt-Version: 0.97\nTag-File-Character-Encoding: UTF-8\n",
+		"bag-info.txt": bagInfo.String(),
+	}
+
+	for _, alg := range b.algorithms {
+		var manifest strings.Builder
+		for _, name := range names {
+			sum, err := checksum(alg, b.files[name])
+			if err != nil {
+				return fmt.Errorf("bagittest: build: %v", err)
+			}
+			fmt.Fprintf(&manifest, "%s  data/%s\n", sum, name)
+		}
+		tagFiles[fmt.Sprintf("manifest-%s.txt", alg)] = manifest.String()
+	}
+
+	tagNames := make([]string, 0, len(tagFiles))
+	for name := range tagFiles {
+		tagNames = append(tagNames, name)
+	}
+	slices.Sort(tagNames)
+	for _, alg := range b.algorithms {
+		var manifest strings.Builder
+		for _, name := range tagNames {
+			sum, err := checksum(alg, tagFiles[name])
+			if err != nil {
+				return fmt.Errorf("bagittest: build: %v", err)
+			}
+			fmt.Fprintf(&manifest, "%s %s\n", sum, name)
+		}
+		tagFiles[fmt.Sprintf("tagmanifest-%s.txt", alg)] = manifest.String()
+	}
+
+	for name, content := range tagFiles {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			return fmt.Errorf("bagittest: build: %v", err)
+		}
+	}
+
+	for _, c := range b.corruptions {
+		if err := c.Apply(dir); err != nil {
+			return fmt.Errorf("bagittest: build: apply %s: %w", c.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// BuildTemp writes the bag to a temporary directory removed when the test
+// ends, and returns its path. It fails the test on error.
+func (b *Builder) BuildTemp(tb testing.TB) string {
+	tb.Helper()
+
+	dir := filepath.Join(tb.TempDir(), "bag")
+	if err := b.Build(dir); err != nil {
+		tb.Fatal(err)
+	}
+
+	return dir
+}
+
+func checksum(alg bagit.Algorithm, content string) (string, error) {
+	var h hash.Hash
+	switch alg {
+	case bagit.MD5:
+		h = md5.New()
+	case bagit.SHA1:
+		h = sha1.New()
+	case bagit.SHA224:
+		h = sha256.New224()
+	case bagit.SHA256:
+		h = sha256.New()
+	case bagit.SHA384:
+		h = sha512.New384()
+	case bagit.SHA512:
+		h = sha512.New()
+	default:
+		return "", fmt.Errorf("unsupported checksum algorithm: %q", alg)
+	}
+	h.Write([]byte(content))
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
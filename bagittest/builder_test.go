@@ -0,0 +1,72 @@
+package bagittest_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/artefactual-labs/bagit-gython"
+	"github.com/artefactual-labs/bagit-gython/bagittest"
+	"gotest.tools/v3/assert"
+)
+
+func TestBuilder(t *testing.T) {
+	t.Parallel()
+
+	python, err := bagit.NewBagIt()
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, python.Cleanup())
+	})
+
+	t.Run("Builds valid bags", func(t *testing.T) {
+		path := bagittest.NewBuilder().
+			WithAlgorithms(bagit.MD5, bagit.SHA1).
+			WithTag("Source-Organization", "Artefactual").
+			WithTag("Contact-Name", "Ada").
+			WithFile("hello.txt", "hello world").
+			WithFile("sub/bye.txt", "bye").
+			BuildTemp(t)
+
+		assert.NilError(t, python.Validate(path))
+
+		bag, err := python.Inspect(path)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, bag.Algorithms, []bagit.Algorithm{bagit.MD5, bagit.SHA1})
+		assert.DeepEqual(t, bag.BagInfo.Values("Source-Organization"), []string{"Artefactual"})
+		assert.DeepEqual(t, *bag.PayloadOxum, bagit.PayloadOxum{Bytes: 14, Files: 2})
+		assert.DeepEqual(t, bag.Manifests[bagit.MD5], []bagit.ManifestEntry{
+			{Path: "data/hello.txt", Checksum: "5eb63bbbe01eeed093cb22bb8f5acdc3"},
+			{Path: "data/sub/bye.txt", Checksum: "bfa99df33b137bc8fb5f5407d7e58da8"},
+		})
+	})
+
+	t.Run("Builds bags without Payload-Oxum", func(t *testing.T) {
+		path := bagittest.NewBuilder().WithoutPayloadOxum().BuildTemp(t)
+
+		assert.NilError(t, python.Validate(path))
+		bag, err := python.Inspect(path)
+		assert.NilError(t, err)
+		assert.Assert(t, bag.PayloadOxum == nil)
+	})
+
+	t.Run("Builds invalid bags", func(t *testing.T) {
+		path := bagittest.NewBuilder().
+			WithFile("hello.txt", "hello world").
+			WithCorruptions(bagittest.FlipByte).
+			BuildTemp(t)
+
+		var verr *bagit.ValidationError
+		assert.Assert(t, errors.As(python.Validate(path), &verr))
+		assert.Equal(t, len(verr.Report.Details), 2)
+	})
+
+	t.Run("Reports corruptions that do not apply", func(t *testing.T) {
+		err := bagittest.NewBuilder().WithCorruptions(bagittest.FlipByte).Build(t.TempDir())
+		assert.ErrorIs(t, err, bagittest.ErrNotApplicable)
+	})
+
+	t.Run("Rejects unsupported algorithms", func(t *testing.T) {
+		err := bagittest.NewBuilder().WithAlgorithms("blake2b").WithFile("a.txt", "a").Build(t.TempDir())
+		assert.Error(t, err, `bagittest: build: unsupported checksum algorithm: "blake2b"`)
+	})
+}
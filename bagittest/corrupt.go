@@ -0,0 +1,226 @@
+package bagittest
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrNotApplicable is returned by a Corruption that cannot break the given bag,
+// e.g. FlipByte when every payload file is empty. The harness skips it.
+var ErrNotApplicable = errors.New("corruption does not apply to this bag")
+
+// Corruption breaks a bag in a known way.
+type Corruption struct {
+	// Name identifies the corruption in results, e.g. "flip-byte".
+	Name string
+
+	// Apply breaks the bag in dir, a copy owned by the harness.
+	Apply func(dir string) error
+}
+
+// Corruptions returns the built-in corruptions.
+func Corruptions() []Corruption {
+	return []Corruption{
+		FlipByte,
+		RemoveFile,
+		ExtraFile,
+		BadOxum,
+		MalformedBagItTxt,
+		UnsafePath,
+		NormalizationConflict,
+	}
+}
+
+var (
+	// FlipByte inverts a byte in the middle of the first non-empty payload
+	// file, keeping its size.
+	FlipByte = Corruption{Name: "flip-byte", Apply: flipByte}
+
+	// RemoveFile removes the first payload file, updating the Payload-Oxum
+	// so that validation reaches the completeness check.
+	RemoveFile = Corruption{Name: "remove-file", Apply: removeFile}
+
+	// ExtraFile adds a payload file that is not listed in the manifests,
+	// updating the Payload-Oxum.
+	ExtraFile = Corruption{Name: "extra-file", Apply: extraFile}
+
+	// BadOxum adds one byte to the byte count of the Payload-Oxum, adding a
+	// Payload-Oxum to bag-info.txt if there is none.
+	BadOxum = Corruption{Name: "bad-oxum", Apply: badOxum}
+
+	// MalformedBagItTxt replaces bagit.txt with a line that is not a tag.
+	MalformedBagItTxt = Corruption{Name: "malformed-bagit-txt", Apply: malformedBagItTxt}
+
+	// UnsafePath lists a file outside the bag in the first payload manifest.
+	UnsafePath = Corruption{Name: "unsafe-path", Apply: unsafePath}
+
+	// NormalizationConflict adds two payload files whose names only differ in
+	// their Unicode normalization form, updating the Payload-Oxum.
+	NormalizationConflict = Corruption{Name: "normalization-conflict", Apply: normalizationConflict}
+)
+
+// payloadFiles lists the regular files below the data directory in lexical
+// order.
+func payloadFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(filepath.Join(dir, "data"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			files = append(files, path)
+		}
+		return nil
+	})
+
+	return files, err
+}
+
+func flipByte(dir string) error {
+	files, err := payloadFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range files {
+		blob, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if len(blob) == 0 {
+			continue
+		}
+		blob[len(blob)/2] ^= 0xff
+		return os.WriteFile(name, blob, 0o644)
+	}
+
+	return ErrNotApplicable
+}
+
+func removeFile(dir string) error {
+	files, err := payloadFiles(dir)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return ErrNotApplicable
+	}
+
+	st, err := os.Stat(files[0])
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(files[0]); err != nil {
+		return err
+	}
+
+	return adjustOxum(dir, -st.Size(), -1)
+}
+
+func extraFile(dir string) error {
+	content := []byte("extra\n")
+	if err := os.WriteFile(filepath.Join(dir, "data", "bagittest-extra.txt"), content, 0o644); err != nil {
+		return err
+	}
+
+	return adjustOxum(dir, int64(len(content)), 1)
+}
+
+var oxumPattern = regexp.MustCompile(`(?m)^Payload-Oxum:\s*(\d+)\.(\d+)\s*$`)
+
+func badOxum(dir string) error {
+	name := filepath.Join(dir, "bag-info.txt")
+	blob, err := os.ReadFile(name)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if !oxumPattern.Match(blob) {
+		if len(blob) > 0 && blob[len(blob)-1] != '\n' {
+			blob = append(blob, '\n')
+		}
+		blob = append(blob, "Payload-Oxum: 1.0\n"...)
+		return os.WriteFile(name, blob, 0o644)
+	}
+
+	return adjustOxum(dir, 1, 0)
+}
+
+// adjustOxum adds bytes and files to the counts of the Payload-Oxum, if the
+// bag has one.
+func adjustOxum(dir string, bytes, files int64) error {
+	name := filepath.Join(dir, "bag-info.txt")
+	blob, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	m := oxumPattern.FindSubmatchIndex(blob)
+	if m == nil {
+		return nil
+	}
+	octets, err := strconv.ParseInt(string(blob[m[2]:m[3]]), 10, 64)
+	if err != nil {
+		return err
+	}
+	streams, err := strconv.ParseInt(string(blob[m[4]:m[5]]), 10, 64)
+	if err != nil {
+		return err
+	}
+	out := slices.Concat(
+		blob[:m[2]],
+		[]byte(strconv.FormatInt(octets+bytes, 10)),
+		blob[m[3]:m[4]],
+		[]byte(strconv.FormatInt(streams+files, 10)),
+		blob[m[5]:],
+	)
+
+	return os.WriteFile(name, out, 0o644)
+}
+
+func malformedBagItTxt(dir string) error {
+	return os.WriteFile(filepath.Join(dir, "bagit.txt"), []byte("This is not a tag\n"), 0o644)
+}
+
+func unsafePath(dir string) error {
+	manifests, err := filepath.Glob(filepath.Join(dir, "manifest-*.txt"))
+	if err != nil {
+		return err
+	}
+	if len(manifests) == 0 {
+		return ErrNotApplicable
+	}
+	slices.Sort(manifests)
+
+	f, err := os.OpenFile(manifests[0], os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(f, "%s  ../outside.txt\n", strings.Repeat("0", 64)); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func normalizationConflict(dir string) error {
+	var bytes int64
+	for _, name := range []string{"bagittest-caf\u00e9.txt", "bagittest-cafe\u0301.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, "data", name), []byte(name), 0o644); err != nil {
+			return err
+		}
+		bytes += int64(len(name))
+	}
+
+	return adjustOxum(dir, bytes, 2)
+}
@@ -0,0 +1,35 @@
+// Package bagittest provides helpers to test code built on bagit-gython.
+//
+// Builder writes valid or broken bags to disk without bagit-python, and Fake
+// stands in for a Validator or a BagIt, returning scripted errors after
+// scripted latencies, so that code handling their results can be unit tested
+// without starting Python:
+//
+//	path := bagittest.NewBuilder().
+//		WithTag("Source-Organization", "Artefactual").
+//		WithFile("hello.txt", "hello world").
+//		WithCorruptions(bagittest.RemoveFile).
+//		BuildTemp(t)
+//
+//	var fake bagittest.Fake
+//	fake.On(path, bagittest.Response{Err: bagit.ErrBusy}, bagittest.Response{
+//		Latency: time.Second,
+//		Err:     bagittest.Invalid("Payload-Oxum validation failed."),
+//	})
+//
+// Harness validates a corpus of bags, plus copies of them broken in known
+// ways, with a reference validator, usually a BagIt running bagit-python, and
+// optionally a candidate validator such as a Validator using BackendNative. It
+// classifies every result so the two can be compared:
+//
+//	b, err := bagit.NewBagIt()
+//	...
+//	h := &bagittest.Harness{Reference: b, Candidate: native}
+//	results, err := h.Run(ctx, "/path/to/bags/a", "/path/to/bags/b")
+//	...
+//	for _, r := range results {
+//		if !r.Agree() {
+//			fmt.Println(r)
+//		}
+//	}
+package bagittest
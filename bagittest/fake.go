@@ -0,0 +1,227 @@
+package bagittest
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/artefactual-labs/bagit-gython"
+)
+
+// Response is a scripted answer of a Fake.
+type Response struct {
+	// Err is returned by the call, e.g. a *bagit.ValidationError, ErrBusy or
+	// a *bagit.RunnerError.
+	Err error
+
+	// Latency delays the call. A call whose context is done first returns
+	// the context error instead.
+	Latency time.Duration
+
+	// Bag is returned by Inspect. Nil returns a Bag holding only the path.
+	Bag *bagit.Bag
+
+	// MakeResult is returned by Make.
+	MakeResult bagit.MakeResult
+}
+
+// Call is a call recorded by a Fake.
+type Call struct {
+	// Method is the name of the method called without its Context or Try
+	// affixes, e.g. "Validate" for TryValidate.
+	Method string
+
+	Path string
+}
+
+// Fake is an in-memory stand-in for a *bagit.Validator or a *bagit.BagIt that
+// returns scripted responses without starting Python, to unit test code
+// handling their results. It is safe for concurrent use.
+//
+// Fake implements bagit.Service and has every other method of *bagit.Validator.
+// The zero value answers every call with the zero Response, i.e. valid bags.
+type Fake struct {
+	mu        sync.Mutex
+	responses map[string][]Response
+	fallback  Response
+	calls     []Call
+}
+
+var _ bagit.Service = (*Fake)(nil)
+
+// On scripts the responses to calls on path, for every method. Calls get the
+// responses in order, then the last one is repeated.
+func (f *Fake) On(path string, rs ...Response) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.responses == nil {
+		f.responses = map[string][]Response{}
+	}
+	f.responses[path] = slices.Clone(rs)
+
+	return f
+}
+
+// OnAny sets the response to calls on paths without scripted responses.
+func (f *Fake) OnAny(r Response) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.fallback = r
+
+	return f
+}
+
+// Calls returns the calls made so far, in order.
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return slices.Clone(f.calls)
+}
+
+func (f *Fake) respond(ctx context.Context, method, path string) Response {
+	f.mu.Lock()
+	f.calls = append(f.calls, Call{Method: method, Path: path})
+	r := f.fallback
+	if rs := f.responses[path]; len(rs) > 0 {
+		r = rs[0]
+		if len(rs) > 1 {
+			f.responses[path] = rs[1:]
+		}
+	}
+	f.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return Response{Err: err}
+	}
+	if r.Latency > 0 {
+		t := time.NewTimer(r.Latency)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return Response{Err: ctx.Err()}
+		case <-t.C:
+		}
+	}
+
+	return r
+}
+
+// PoolSize returns 1.
+func (f *Fake) PoolSize() int {
+	return 1
+}
+
+// Health returns nil.
+func (f *Fake) Health(ctx context.Context) error {
+	return nil
+}
+
+// Stats reports a pool of one idle runner.
+func (f *Fake) Stats() bagit.PoolStats {
+	return bagit.PoolStats{MinRunners: 1, MaxRunners: 1, Runners: 1, Idle: 1}
+}
+
+func (f *Fake) Validate(path string, opts ...bagit.ValidateOption) error {
+	return f.ValidateContext(context.Background(), path, opts...)
+}
+
+func (f *Fake) ValidateContext(ctx context.Context, path string, opts ...bagit.ValidateOption) error {
+	return f.respond(ctx, "Validate", path).Err
+}
+
+func (f *Fake) TryValidate(path string, opts ...bagit.ValidateOption) error {
+	return f.ValidateContext(context.Background(), path, opts...)
+}
+
+func (f *Fake) Make(path string, opts bagit.MakeOptions) (bagit.MakeResult, error) {
+	return f.MakeContext(context.Background(), path, opts)
+}
+
+func (f *Fake) MakeContext(ctx context.Context, path string, opts bagit.MakeOptions) (bagit.MakeResult, error) {
+	r := f.respond(ctx, "Make", path)
+	if r.Err != nil {
+		return bagit.MakeResult{}, r.Err
+	}
+
+	return r.MakeResult, nil
+}
+
+func (f *Fake) TryMake(path string, opts bagit.MakeOptions) (bagit.MakeResult, error) {
+	return f.MakeContext(context.Background(), path, opts)
+}
+
+func (f *Fake) Update(path string, opts bagit.UpdateOptions) error {
+	return f.UpdateContext(context.Background(), path, opts)
+}
+
+func (f *Fake) UpdateContext(ctx context.Context, path string, opts bagit.UpdateOptions) error {
+	return f.respond(ctx, "Update", path).Err
+}
+
+func (f *Fake) TryUpdate(path string, opts bagit.UpdateOptions) error {
+	return f.UpdateContext(context.Background(), path, opts)
+}
+
+func (f *Fake) Inspect(path string) (*bagit.Bag, error) {
+	return f.InspectContext(context.Background(), path)
+}
+
+func (f *Fake) InspectContext(ctx context.Context, path string) (*bagit.Bag, error) {
+	r := f.respond(ctx, "Inspect", path)
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	if r.Bag == nil {
+		return &bagit.Bag{Path: path}, nil
+	}
+
+	return r.Bag, nil
+}
+
+func (f *Fake) TryInspect(path string) (*bagit.Bag, error) {
+	return f.InspectContext(context.Background(), path)
+}
+
+func (f *Fake) ValidateProfile(path string, profile *bagit.Profile) error {
+	return f.ValidateProfileContext(context.Background(), path, profile)
+}
+
+func (f *Fake) ValidateProfileContext(ctx context.Context, path string, profile *bagit.Profile) error {
+	return f.respond(ctx, "ValidateProfile", path).Err
+}
+
+func (f *Fake) TryValidateProfile(path string, profile *bagit.Profile) error {
+	return f.ValidateProfileContext(context.Background(), path, profile)
+}
+
+func (f *Fake) Complete(path string, fetcher bagit.Fetcher, opts bagit.CompleteOptions) error {
+	return f.CompleteContext(context.Background(), path, fetcher, opts)
+}
+
+func (f *Fake) CompleteContext(ctx context.Context, path string, fetcher bagit.Fetcher, opts bagit.CompleteOptions) error {
+	return f.respond(ctx, "Complete", path).Err
+}
+
+func (f *Fake) TryComplete(path string, fetcher bagit.Fetcher, opts bagit.CompleteOptions) error {
+	return f.CompleteContext(context.Background(), path, fetcher, opts)
+}
+
+// Close does nothing.
+func (f *Fake) Close() error {
+	return nil
+}
+
+// Cleanup does nothing.
+func (f *Fake) Cleanup() error {
+	return nil
+}
+
+// Invalid returns a *bagit.ValidationError reporting details, or a structure
+// error when there are none, to script a failed validation.
+func Invalid(message string, details ...bagit.ValidationDetail) error {
+	return bagit.NewValidationError(bagit.ValidationReport{Message: message, Details: details})
+}
@@ -0,0 +1,99 @@
+package bagittest_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/artefactual-labs/bagit-gython"
+	"github.com/artefactual-labs/bagit-gython/bagittest"
+	"gotest.tools/v3/assert"
+)
+
+func TestFake(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Has the methods of Validator and BagIt", func(t *testing.T) {
+		fake := reflect.TypeFor[*bagittest.Fake]()
+		for _, typ := range []reflect.Type{reflect.TypeFor[*bagit.Validator](), reflect.TypeFor[*bagit.BagIt]()} {
+			for m := range typ.Methods() {
+				got, ok := fake.MethodByName(m.Name)
+				assert.Assert(t, ok, "missing %s.%s", typ, m.Name)
+				assert.Equal(t, got.Type.String(), reflect.FuncOf(
+					append([]reflect.Type{fake}, params(m.Type)[1:]...),
+					results(m.Type),
+					m.Type.IsVariadic(),
+				).String(), m.Name)
+			}
+		}
+	})
+
+	t.Run("Answers valid by default", func(t *testing.T) {
+		var f bagittest.Fake
+
+		assert.NilError(t, f.Validate("a"))
+		bag, err := f.Inspect("a")
+		assert.NilError(t, err)
+		assert.Equal(t, bag.Path, "a")
+		assert.DeepEqual(t, f.Calls(), []bagittest.Call{{Method: "Validate", Path: "a"}, {Method: "Inspect", Path: "a"}})
+	})
+
+	t.Run("Returns scripted responses in order", func(t *testing.T) {
+		var f bagittest.Fake
+		f.On("a",
+			bagittest.Response{Err: bagit.ErrBusy},
+			bagittest.Response{Err: bagittest.Invalid("Payload-Oxum validation failed.")},
+		).OnAny(bagittest.Response{Err: bagittest.Invalid(
+			"Bag validation failed: data/a.txt exists in manifest but was not found on filesystem",
+			bagit.ValidationDetail{Type: bagit.FileMissing, Path: "data/a.txt"},
+		)})
+
+		assert.ErrorIs(t, f.TryValidate("a"), bagit.ErrBusy)
+
+		err := f.Validate("a")
+		var serr *bagit.StructureError
+		assert.Assert(t, errors.As(err, &serr))
+		assert.Equal(t, serr.Message, "Payload-Oxum validation failed.")
+		assert.ErrorIs(t, f.Validate("a"), bagit.ErrInvalid)
+
+		err = f.Validate("b")
+		assert.Error(t, err, "invalid: Bag validation failed: data/a.txt exists in manifest but was not found on filesystem")
+		var ferr *bagit.FileMissingError
+		assert.Assert(t, errors.As(err, &ferr))
+		assert.Equal(t, ferr.Path, "data/a.txt")
+	})
+
+	t.Run("Waits for the latency or the context", func(t *testing.T) {
+		var f bagittest.Fake
+		f.OnAny(bagittest.Response{Latency: 20 * time.Millisecond, MakeResult: bagit.MakeResult{Version: "1.0"}})
+
+		start := time.Now()
+		res, err := f.Make("a", bagit.MakeOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, res.Version, "1.0")
+		assert.Assert(t, time.Since(start) >= 20*time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		f.OnAny(bagittest.Response{Latency: time.Minute})
+		assert.ErrorIs(t, f.ValidateContext(ctx, "a"), context.DeadlineExceeded)
+	})
+}
+
+func params(t reflect.Type) []reflect.Type {
+	var in []reflect.Type
+	for i := range t.NumIn() {
+		in = append(in, t.In(i))
+	}
+	return in
+}
+
+func results(t reflect.Type) []reflect.Type {
+	var out []reflect.Type
+	for i := range t.NumOut() {
+		out = append(out, t.Out(i))
+	}
+	return out
+}
@@ -0,0 +1,318 @@
+package bagittest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/artefactual-labs/bagit-gython"
+)
+
+// Outcome is the class of a validation result.
+type Outcome string
+
+const (
+	// OutcomeValid is a bag that passed validation.
+	OutcomeValid Outcome = "valid"
+
+	// OutcomeInvalid is a *bagit.ValidationError listing problems, e.g.
+	// checksum mismatches or missing files.
+	OutcomeInvalid Outcome = "invalid"
+
+	// OutcomeStructure is a *bagit.ValidationError without problems, i.e. a
+	// bag that could not be checked against its manifests.
+	OutcomeStructure Outcome = "structure"
+
+	// OutcomeError is any other error, e.g. a *bagit.RunnerError.
+	OutcomeError Outcome = "error"
+)
+
+// Problem is a problem reported by a validation, a bagit.ValidationDetail
+// without its message.
+type Problem struct {
+	Type      bagit.ValidationDetailType
+	Path      string
+	Algorithm string
+}
+
+// Classification describes the result of a validation.
+type Classification struct {
+	Outcome Outcome
+
+	// ErrorType is the type of the *bagit.BagError, e.g. "BagValidationError".
+	ErrorType string
+
+	// Problems lists the problems of an OutcomeInvalid result, sorted.
+	Problems []Problem
+
+	// Message is the error message. It is not compared by Equal.
+	Message string
+}
+
+// Classify classifies the error returned by a validation.
+func Classify(err error) Classification {
+	if err == nil {
+		return Classification{Outcome: OutcomeValid}
+	}
+
+	var verr *bagit.ValidationError
+	if !errors.As(err, &verr) {
+		return Classification{Outcome: OutcomeError, Message: err.Error()}
+	}
+
+	c := Classification{Outcome: OutcomeStructure, Message: verr.Report.Message}
+	var berr *bagit.BagError
+	if errors.As(err, &berr) {
+		c.ErrorType = berr.Type
+	}
+	for _, d := range verr.Report.Details {
+		c.Problems = append(c.Problems, Problem{Type: d.Type, Path: d.Path, Algorithm: d.Algorithm})
+	}
+	if len(c.Problems) > 0 {
+		c.Outcome = OutcomeInvalid
+		slices.SortFunc(c.Problems, func(a, b Problem) int {
+			return strings.Compare(a.String(), b.String())
+		})
+	}
+
+	return c
+}
+
+// Equal reports whether c and o have the same outcome, error type and
+// problems.
+func (c Classification) Equal(o Classification) bool {
+	return c.Outcome == o.Outcome && c.ErrorType == o.ErrorType && slices.Equal(c.Problems, o.Problems)
+}
+
+func (c Classification) String() string {
+	s := string(c.Outcome)
+	if c.ErrorType != "" {
+		s = fmt.Sprintf("%s (%s)", s, c.ErrorType)
+	}
+	if len(c.Problems) > 0 {
+		problems := make([]string, 0, len(c.Problems))
+		for _, p := range c.Problems {
+			problems = append(problems, p.String())
+		}
+		s = fmt.Sprintf("%s: %s", s, strings.Join(problems, ", "))
+	}
+
+	return s
+}
+
+func (p Problem) String() string {
+	if p.Algorithm == "" {
+		return fmt.Sprintf("%s %s", p.Type, p.Path)
+	}
+
+	return fmt.Sprintf("%s %s %s", p.Type, p.Path, p.Algorithm)
+}
+
+// Result is the classification of a bag of the corpus, as is or broken by a
+// corruption.
+type Result struct {
+	// Bag is the path of the bag in the corpus.
+	Bag string
+
+	// Corruption is the name of the corruption applied, empty for the bag
+	// as is.
+	Corruption string
+
+	Reference Classification
+
+	// Candidate is nil when the harness has no candidate validator.
+	Candidate *Classification
+}
+
+// Agree reports whether the reference and candidate validators classified the
+// bag the same way. It is true without a candidate.
+func (r Result) Agree() bool {
+	return r.Candidate == nil || r.Reference.Equal(*r.Candidate)
+}
+
+func (r Result) String() string {
+	name := r.Bag
+	if r.Corruption != "" {
+		name = fmt.Sprintf("%s [%s]", name, r.Corruption)
+	}
+	if r.Candidate == nil || r.Agree() {
+		return fmt.Sprintf("%s: %s", name, r.Reference)
+	}
+
+	return fmt.Sprintf("%s: reference %s, candidate %s", name, r.Reference, *r.Candidate)
+}
+
+// Harness validates a corpus of bags and broken copies of them, comparing the
+// results of a reference and a candidate validator.
+type Harness struct {
+	// Reference is the validator the candidate is compared to, usually a
+	// *bagit.BagIt running bagit-python. It is required.
+	Reference bagit.BagValidator
+
+	// Candidate is the validator under test. Without one, Run only
+	// classifies the results of the reference.
+	Candidate bagit.BagValidator
+
+	// Corruptions are applied to a copy of every bag directory. Nil uses
+	// Corruptions(), an empty slice none.
+	Corruptions []Corruption
+
+	// Options are passed to every validation.
+	Options []bagit.ValidateOption
+}
+
+// Run validates every bag as is, then a copy of it broken by each corruption,
+// returning one Result per validation. Bags may be directories or serialized
+// bags, which are only validated as is. Corruptions returning
+// ErrNotApplicable are skipped.
+//
+// Copies are made in a temporary directory removed before Run returns.
+func (h *Harness) Run(ctx context.Context, bags ...string) ([]Result, error) {
+	if h.Reference == nil {
+		return nil, errors.New("bagittest: harness has no reference validator")
+	}
+	corruptions := h.Corruptions
+	if corruptions == nil {
+		corruptions = Corruptions()
+	}
+
+	tmpDir, err := os.MkdirTemp("", "bagittest-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var results []Result
+	for i, bag := range bags {
+		r, err := h.check(ctx, bag, "", bag)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+
+		if st, err := os.Stat(bag); err != nil || !st.IsDir() {
+			continue
+		}
+		for j, c := range corruptions {
+			dir := filepath.Join(tmpDir, strconv.Itoa(i), strconv.Itoa(j), filepath.Base(bag))
+			if err := copyDir(bag, dir); err != nil {
+				return nil, fmt.Errorf("bagittest: copy %s: %v", bag, err)
+			}
+
+			err := c.Apply(dir)
+			if errors.Is(err, ErrNotApplicable) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("bagittest: %s: apply %s: %v", bag, c.Name, err)
+			}
+
+			r, err := h.check(ctx, bag, c.Name, dir)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, r)
+
+			if err := os.RemoveAll(dir); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return results, nil
+}
+
+func (h *Harness) check(ctx context.Context, bag, corruption, path string) (Result, error) {
+	r := Result{Bag: bag, Corruption: corruption}
+
+	err := h.Reference.ValidateContext(ctx, path, h.Options...)
+	if ctx.Err() != nil {
+		return r, ctx.Err()
+	}
+	r.Reference = Classify(err)
+
+	if h.Candidate != nil {
+		err := h.Candidate.ValidateContext(ctx, path, h.Options...)
+		if ctx.Err() != nil {
+			return r, ctx.Err()
+		}
+		c := Classify(err)
+		r.Candidate = &c
+	}
+
+	return r, nil
+}
+
+// FindBags returns the directories below root holding a bagit.txt file,
+// without looking for bags inside bags.
+func FindBags(root string) ([]string, error) {
+	var bags []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		if _, err := os.Stat(filepath.Join(path, "bagit.txt")); err == nil {
+			bags = append(bags, path)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+
+	return bags, err
+}
+
+// copyDir copies the directories, regular files and symbolic links below src
+// to dst.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, 0o755)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			return copyFile(path, target)
+		default:
+			return nil
+		}
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
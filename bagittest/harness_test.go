@@ -0,0 +1,106 @@
+package bagittest_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/artefactual-labs/bagit-gython"
+	"github.com/artefactual-labs/bagit-gython/bagittest"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestHarness(t *testing.T) {
+	t.Parallel()
+
+	python, err := bagit.NewBagIt()
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, python.Cleanup())
+	})
+	native, err := bagit.NewValidator(bagit.WithBackend(bagit.BackendNative), bagit.WithTempCacheDir())
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, native.Close())
+	})
+
+	dir := fs.NewDir(t, "", fs.WithDir("corpus", fs.WithDir("bag",
+		fs.WithFile("hello.txt", "hello world"),
+		fs.WithDir("sub", fs.WithFile("bye.txt", "bye")),
+	)))
+	_, err = python.Make(dir.Join("corpus", "bag"), bagit.MakeOptions{})
+	assert.NilError(t, err)
+
+	t.Run("Finds bags", func(t *testing.T) {
+		bags, err := bagittest.FindBags(dir.Path())
+		assert.NilError(t, err)
+		assert.DeepEqual(t, bags, []string{dir.Join("corpus", "bag")})
+	})
+
+	t.Run("Classifies corruptions", func(t *testing.T) {
+		h := bagittest.Harness{Reference: python, Candidate: native}
+		results, err := h.Run(context.Background(), dir.Join("corpus", "bag"))
+		assert.NilError(t, err)
+
+		want := map[string]bagittest.Outcome{
+			"":                       bagittest.OutcomeValid,
+			"flip-byte":              bagittest.OutcomeInvalid,
+			"remove-file":            bagittest.OutcomeInvalid,
+			"extra-file":             bagittest.OutcomeInvalid,
+			"bad-oxum":               bagittest.OutcomeStructure,
+			"malformed-bagit-txt":    bagittest.OutcomeStructure,
+			"unsafe-path":            bagittest.OutcomeStructure,
+			"normalization-conflict": bagittest.OutcomeInvalid,
+		}
+		got := map[string]bagittest.Outcome{}
+		for _, r := range results {
+			assert.Equal(t, r.Bag, dir.Join("corpus", "bag"))
+			assert.Assert(t, r.Agree(), r.String())
+			got[r.Corruption] = r.Reference.Outcome
+		}
+		assert.DeepEqual(t, got, want)
+	})
+
+	t.Run("Works without a candidate", func(t *testing.T) {
+		h := bagittest.Harness{Reference: python, Corruptions: []bagittest.Corruption{bagittest.FlipByte}}
+		results, err := h.Run(context.Background(), dir.Join("corpus", "bag"))
+		assert.NilError(t, err)
+		assert.Equal(t, len(results), 2)
+		assert.Assert(t, results[1].Candidate == nil)
+		assert.Equal(t, results[1].String(), dir.Join("corpus", "bag")+
+			" [flip-byte]: invalid (BagValidationError): ChecksumMismatch data/hello.txt sha256, ChecksumMismatch data/hello.txt sha512")
+	})
+
+	t.Run("Skips corruptions that do not apply", func(t *testing.T) {
+		h := bagittest.Harness{Reference: python, Corruptions: []bagittest.Corruption{bagittest.FlipByte}}
+		results, err := h.Run(context.Background(), filepath.Join("..", "internal", "testdata", "valid-bag"))
+		assert.NilError(t, err)
+		assert.Equal(t, len(results), 1)
+	})
+
+	t.Run("Reports corruption errors", func(t *testing.T) {
+		h := bagittest.Harness{Reference: python, Corruptions: []bagittest.Corruption{{
+			Name:  "broken",
+			Apply: func(string) error { return errors.New("boom") },
+		}}}
+		_, err := h.Run(context.Background(), dir.Join("corpus", "bag"))
+		assert.ErrorContains(t, err, "apply broken: boom")
+	})
+
+	t.Run("Requires a reference", func(t *testing.T) {
+		_, err := (&bagittest.Harness{}).Run(context.Background())
+		assert.Error(t, err, "bagittest: harness has no reference validator")
+	})
+}
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	assert.DeepEqual(t, bagittest.Classify(nil), bagittest.Classification{Outcome: bagittest.OutcomeValid})
+	assert.DeepEqual(t, bagittest.Classify(errors.New("boom")), bagittest.Classification{
+		Outcome: bagittest.OutcomeError,
+		Message: "boom",
+	})
+}
@@ -17,9 +17,27 @@
 //		return err
 //	}
 //
+// WithPoolBounds lets the pool grow on demand between a minimum and a maximum
+// number of runners, and WithIdleTimeout stops the runners above the minimum
+// once they have been idle for a while. Validator.Stats reports the current
+// size of the pool. WithWarmup starts the runner processes while bootstrapping,
+// WithHealthCheck replaces the runners that stop answering, and
+// Validator.Health checks a runner on demand, e.g. for a readiness probe.
+// WithMaxRequestsPerRunner and WithMaxRunnerRSS replace the runners that have
+// answered too many commands or use too much memory.
+//
 // Validator.Validate waits when all runners are busy. Validator.ValidateContext
-// lets callers cancel that wait, and Validator.TryValidate returns ErrBusy
-// immediately when no runner is available.
+// lets callers cancel that wait or the validation itself, which terminates the
+// runner process, and Validator.TryValidate returns ErrBusy immediately when no
+// runner is available. Validator.Make, MakeContext and
+// TryMake create bags on the same pool with the same semantics, Update,
+// UpdateContext and TryUpdate change the metadata of existing bags, and
+// Validator.Inspect, InspectContext and TryInspect read a bag into a *Bag
+// without validating it. ValidateProfile, ValidateProfileContext and
+// TryValidateProfile check a bag against a BagIt Profile loaded with
+// LoadProfile or ParseProfile, reporting a *ProfileError that lists every
+// violation. Complete, CompleteContext and TryComplete download the files
+// listed in fetch.txt of holey bags with a Fetcher, then validate them.
 //
 // By default, Validator caches extracted runtime files below the user's cache
 // directory in "bagit-gython" so later validators and process starts can reuse
@@ -37,6 +55,58 @@
 // processing another command can return ErrBusy. Use one BagIt per concurrent
 // caller, serialize access yourself, or use Validator.
 //
-// Both APIs return ErrInvalid for validation failures. Release resources with
-// Validator.Close or BagIt.Cleanup when the runner is no longer needed.
+// Validate also accepts zip, tar, and gzip or Zstandard compressed tar files
+// holding a bag as their single top-level directory. They are extracted into a
+// temporary directory for the duration of the validation. Serialize writes
+// such archives reproducibly, without an embedded runtime.
+//
+// Both APIs return a *ValidationError wrapping ErrInvalid for validation
+// failures. Its ValidationReport lists every checksum mismatch, missing file,
+// unexpected file and normalization conflict found by bagit-python:
+//
+//	var verr *bagit.ValidationError
+//	if errors.As(err, &verr) {
+//		for _, detail := range verr.Report.Details {
+//			fmt.Println(detail.Type, detail.Path)
+//		}
+//	}
+//
+// Errors mirror the bagit-python exceptions. A *ValidationError also wraps the
+// *BagError raised by bagit-python and either a *StructureError or one
+// *ChecksumMismatchError, *FileMissingError or *UnexpectedFileError per problem
+// found. Bag paths that do not exist are reported with a *BagError matching
+// fs.ErrNotExist, which does not wrap ErrInvalid. Failures of the embedded
+// Python process are reported with a *RunnerError instead, which does not wrap
+// ErrInvalid either.
+//
+// WithBackend(BackendNative) makes a Validator validate bags in Go, with the
+// same checks and error types as bagit-python, which remains the default and
+// reference implementation. The embedded runtime is then only started for
+// commands without a native implementation. Package bagittest compares two
+// validators on a corpus of bags and broken copies of them.
+//
+// WithProgress and MakeOptions.Progress report the phase of a command and the
+// number of files and bytes hashed while checksums are computed.
+//
+// WithLogger, WithMaxRestarts and WithRequestTimeout apply to both
+// NewValidator and NewBagIt. WithRequestTimeout terminates the runner of a
+// command that runs for too long, which then returns ErrTimeout.
+// WithLogger streams the standard error of the runner processes and the Python
+// logging records of bagit-python to a *slog.Logger.
+//
+// WithMetrics reports the pool usage, validation durations by outcome, runner
+// restarts and bytes hashed by a Validator to a Metrics implementation, such as
+// the Prometheus collectors of package bagitprom, a separate module that keeps
+// the Prometheus client out of the dependencies of this one.
+//
+// WithTracerProvider records OpenTelemetry spans of validations, runner
+// acquisition, runtime extraction and runner commands, including the phases
+// timed inside the Python runner.
+//
+// *Validator and *BagIt implement the BagValidator and Service interfaces.
+// Intercept wraps a Service with interceptors such as LoggingInterceptor,
+// RetryInterceptor and MetricsInterceptor.
+//
+// Release resources with Validator.Close or BagIt.Cleanup when the runner is no
+// longer needed.
 package bagit
@@ -0,0 +1,246 @@
+package bagit
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// ValidationError is returned when a bag fails validation. It wraps ErrInvalid
+// and carries the ValidationReport built from the bagit-python error, use
+// errors.As to access it.
+//
+// ValidationError also wraps the *BagError raised by bagit-python and, when
+// available, a more specific error: a *StructureError when the bag could not be
+// checked against its manifests, or one *ChecksumMismatchError,
+// *FileMissingError or *UnexpectedFileError per problem in the report.
+//
+// Use NewValidationError to build a ValidationError wrapping the specific
+// errors of a report, e.g. in a test fake.
+type ValidationError struct {
+	Report ValidationReport
+
+	errs []error
+}
+
+// NewValidationError returns a ValidationError for report, wrapping
+// ErrInvalid and the specific errors of its details, or a *StructureError when
+// it has none.
+func NewValidationError(report ValidationReport) *ValidationError {
+	return &ValidationError{Report: report, errs: report.errs()}
+}
+
+func newValidationError(err *BagError, details []ValidationDetail) *ValidationError {
+	report := ValidationReport{Message: err.Message, Details: details}
+
+	return &ValidationError{
+		Report: report,
+		errs:   append([]error{err}, report.errs()...),
+	}
+}
+
+func (e *ValidationError) Error() string {
+	if e.Report.Message == "" {
+		return ErrInvalid.Error()
+	}
+
+	return fmt.Sprintf("%v: %s", ErrInvalid, e.Report.Message)
+}
+
+func (e *ValidationError) Unwrap() []error {
+	return append([]error{ErrInvalid}, e.errs...)
+}
+
+// errs returns a *StructureError when r has no details, or the specific error
+// of every detail.
+func (r ValidationReport) errs() []error {
+	if len(r.Details) == 0 {
+		return []error{&StructureError{Message: r.Message}}
+	}
+
+	var errs []error
+	for _, detail := range r.Details {
+		if err := detail.err(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// BagError reports a failure raised by bagit-python while working on a bag,
+// e.g. a missing bagit.txt or an unusable bag directory.
+//
+// When the bag path does not exist, BagError wraps the corresponding
+// *fs.PathError so that errors.Is(err, fs.ErrNotExist) reports true.
+type BagError struct {
+	// Type is the name of the bagit-python exception class, e.g. "BagError"
+	// or "BagValidationError".
+	Type string
+
+	// Message is the error message reported by bagit-python.
+	Message string
+
+	// Path is the bag path given to the command.
+	Path string
+
+	err error
+}
+
+func (e *BagError) Error() string {
+	return e.Message
+}
+
+func (e *BagError) Unwrap() error {
+	return e.err
+}
+
+// StructureError reports a bag that could not be checked against its
+// manifests because its structure or metadata is invalid, e.g. a missing
+// bagit.txt or data directory, a malformed tag file or a Payload-Oxum mismatch.
+type StructureError struct {
+	Message string
+}
+
+func (e *StructureError) Error() string {
+	return e.Message
+}
+
+// ChecksumMismatchError reports a file whose computed checksum does not match
+// the value recorded in the manifest.
+type ChecksumMismatchError struct {
+	Path      string
+	Algorithm string
+	Expected  string
+	Found     string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf(`%s %s validation failed: expected="%s" found="%s"`, e.Path, e.Algorithm, e.Expected, e.Found)
+}
+
+// FileMissingError reports a file listed in a manifest that was not found on
+// the filesystem.
+type FileMissingError struct {
+	Path string
+}
+
+func (e *FileMissingError) Error() string {
+	return fmt.Sprintf("%s exists in manifest but was not found on filesystem", e.Path)
+}
+
+// UnexpectedFileError reports a payload file that is not listed in any
+// manifest.
+type UnexpectedFileError struct {
+	Path string
+}
+
+func (e *UnexpectedFileError) Error() string {
+	return fmt.Sprintf("%s exists on filesystem but is not in the manifest", e.Path)
+}
+
+// RunnerError reports a failure of the embedded Python runner rather than a
+// problem with the bag, e.g. the process could not be started, stopped
+// responding, or raised an unexpected Python exception.
+type RunnerError struct {
+	// Type is the name of the Python exception class, empty when the failure
+	// was detected by the Go side of the runner.
+	Type string
+
+	// Message describes the failure.
+	Message string
+
+	// Err is the underlying error, if any.
+	Err error
+
+	// Crashed reports whether the runner process exited or stopped responding
+	// while executing the command. The next command starts a new process.
+	Crashed bool
+
+	// ExitCode is the exit code of a crashed runner process, or -1 if it was
+	// terminated by a signal.
+	ExitCode int
+
+	// Stderr holds the last lines written to standard error by a crashed
+	// runner process, e.g. a Python traceback.
+	Stderr []string
+}
+
+func (e *RunnerError) Error() string {
+	msg := e.Message
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	if e.Crashed {
+		msg = fmt.Sprintf("%s (runner exit code %d)", msg, e.ExitCode)
+		if n := len(e.Stderr); n > 0 {
+			msg = fmt.Sprintf("%s: %s", msg, e.Stderr[n-1])
+		}
+	}
+
+	return msg
+}
+
+func (e *RunnerError) Unwrap() error {
+	return e.Err
+}
+
+// errorResponse holds the error fields shared by every runner response.
+type errorResponse struct {
+	Err     string             `json:"err"`
+	Type    string             `json:"type"`
+	Kind    string             `json:"kind"` // "bag" or "runner".
+	Details []ValidationDetail `json:"details"`
+}
+
+// asError returns the typed error described by r, or nil if the command
+// succeeded. A bag error is returned as *BagError, anything else as
+// *RunnerError.
+func (r errorResponse) asError(path string) error {
+	if r.Err == "" {
+		return nil
+	}
+
+	if r.Kind != "bag" {
+		return &RunnerError{Type: r.Type, Message: r.Err}
+	}
+
+	err := &BagError{Type: r.Type, Message: r.Err, Path: path}
+	if _, statErr := os.Stat(path); errors.Is(statErr, fs.ErrNotExist) {
+		err.err = statErr
+	}
+
+	return err
+}
+
+// validationError returns the error described by r for the bag at path, or
+// nil if the command succeeded. A bag error is returned as *ValidationError,
+// unless path does not exist: the *BagError is then returned as is, since
+// there is no bag to be invalid.
+func (r errorResponse) validationError(path string) error {
+	err := r.asError(path)
+	if berr, ok := err.(*BagError); ok && berr.err == nil {
+		return newValidationError(berr, r.Details)
+	}
+
+	return err
+}
+
+func (d ValidationDetail) err() error {
+	switch d.Type {
+	case ChecksumMismatch:
+		return &ChecksumMismatchError{
+			Path:      d.Path,
+			Algorithm: d.Algorithm,
+			Expected:  d.Expected,
+			Found:     d.Found,
+		}
+	case FileMissing:
+		return &FileMissingError{Path: d.Path}
+	case UnexpectedFile:
+		return &UnexpectedFileError{Path: d.Path}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,341 @@
+package bagit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Fetcher retrieves the files listed in fetch.txt of holey bags, see
+// Complete.
+type Fetcher interface {
+	// Fetch returns the contents of the resource at u starting at byte
+	// offset, which is non-zero when resuming an interrupted download. The
+	// contents are empty if offset is the size of the resource.
+	Fetch(ctx context.Context, u *url.URL, offset int64) (io.ReadCloser, error)
+}
+
+// Fetchers is a Fetcher delegating to the Fetcher registered for the scheme
+// of each URL.
+type Fetchers map[string]Fetcher
+
+func (f Fetchers) Fetch(ctx context.Context, u *url.URL, offset int64) (io.ReadCloser, error) {
+	fetcher, ok := f[u.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("unsupported URL scheme: %q", u.Scheme)
+	}
+
+	return fetcher.Fetch(ctx, u, offset)
+}
+
+// DefaultFetcher returns a Fetcher for http and https URLs, using
+// http.DefaultClient. File URLs are not supported, since fetch.txt comes with
+// the bag and could list any file readable by the process: add a FileFetcher
+// limited to a trusted directory to fetch them.
+func DefaultFetcher() Fetchers {
+	return Fetchers{
+		"http":  HTTPFetcher{},
+		"https": HTTPFetcher{},
+	}
+}
+
+// FileFetcher fetches file URLs from the local file system, e.g.
+// "file:///srv/data/file.txt".
+type FileFetcher struct {
+	// Root is the directory holding the files that may be fetched, required.
+	// URLs of files outside of it, including through symbolic links, are
+	// rejected.
+	Root string
+}
+
+func (f FileFetcher) Fetch(ctx context.Context, u *url.URL, offset int64) (io.ReadCloser, error) {
+	if f.Root == "" {
+		return nil, errors.New("file fetcher has no root directory")
+	}
+	if u.Host != "" && u.Host != "localhost" {
+		return nil, fmt.Errorf("unsupported file URL host: %q", u.Host)
+	}
+
+	dir, err := filepath.Abs(f.Root)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(dir, filepath.FromSlash(u.Path))
+	if err != nil || !filepath.IsLocal(rel) {
+		return nil, fmt.Errorf("file URL outside of %s: %q", f.Root, u.Path)
+	}
+
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+
+	file, err := root.Open(rel)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// HTTPFetcher fetches http and https URLs. Interrupted downloads are resumed
+// with range requests, falling back to skipping the first bytes of the
+// response when the server does not support them. A range that is not
+// satisfiable is taken as the end of a download that was already complete.
+type HTTPFetcher struct {
+	// Client sends the requests, http.DefaultClient if nil.
+	Client *http.Client
+}
+
+func (f HTTPFetcher) Fetch(ctx context.Context, u *url.URL, offset int64) (io.ReadCloser, error) {
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case offset > 0 && resp.StatusCode == http.StatusPartialContent:
+	case offset > 0 && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		resp.Body.Close()
+		return http.NoBody, nil
+	case resp.StatusCode == http.StatusOK:
+		if _, err := io.CopyN(io.Discard, resp.Body, offset); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
+// CompleteOptions configures the completion of a holey bag. The zero value
+// uses four concurrent downloads and the bagit-python validation defaults.
+type CompleteOptions struct {
+	// Concurrency is the maximum number of concurrent downloads. Values lower
+	// than one use four.
+	Concurrency int
+
+	// Processes is the number of processes used to validate the completed
+	// bag, see WithProcesses.
+	Processes int
+}
+
+// partialSuffix is appended to the name of the files being downloaded.
+const partialSuffix = ".part"
+
+// Complete downloads the files listed in fetch.txt of the bag at path that
+// are missing, then fully validates the bag, see CompleteContext.
+func (b *BagIt) Complete(path string, fetcher Fetcher, opts CompleteOptions) error {
+	return b.CompleteContext(context.Background(), path, fetcher, opts)
+}
+
+// CompleteContext downloads the files listed in fetch.txt of the bag at path
+// with fetcher, DefaultFetcher if nil, then fully validates the bag, see
+// Validate.
+//
+// Files that already exist with the size declared in fetch.txt are skipped.
+// Files are downloaded next to their destination with a ".part" suffix and
+// renamed once complete, so calling CompleteContext again after a failure or
+// cancellation resumes the interrupted downloads. A file whose size does not
+// match the size declared in fetch.txt is reported as an error, as is a path
+// outside of the payload directory or below a symbolic link.
+//
+// If ctx is done first, CompleteContext returns ctx.Err().
+func (b *BagIt) CompleteContext(ctx context.Context, path string, fetcher Fetcher, opts CompleteOptions) error {
+	bag, err := b.InspectContext(ctx, path)
+	if err != nil {
+		return err
+	}
+	if err := fetchAll(ctx, fetcher, path, bag.Fetch, opts); err != nil {
+		return err
+	}
+
+	return b.ValidateContext(ctx, path, WithProcesses(opts.Processes))
+}
+
+// fetchAll downloads the files of entries into the bag at root concurrently.
+func fetchAll(ctx context.Context, fetcher Fetcher, root string, entries []FetchEntry, opts CompleteOptions) error {
+	if fetcher == nil {
+		fetcher = DefaultFetcher()
+	}
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 4
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for _, entry := range entries {
+		g.Go(func() error {
+			if err := fetchEntry(gctx, fetcher, root, entry); err != nil {
+				return fmt.Errorf("fetch %s: %w", entry.URL, err)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("complete: %w", err)
+	}
+
+	return nil
+}
+
+// fetchEntry downloads the file of entry into the bag at root unless it
+// exists, resuming a previous partial download. Files are opened within root
+// and symbolic links are refused, so that a bag cannot make a download write
+// anywhere else.
+func fetchEntry(ctx context.Context, fetcher Fetcher, root string, entry FetchEntry) error {
+	u, err := url.Parse(entry.URL)
+	if err != nil {
+		return err
+	}
+	rel := filepath.FromSlash(entry.Path)
+	if !filepath.IsLocal(rel) || !strings.HasPrefix(path.Clean(entry.Path), "data/") {
+		return fmt.Errorf("unsafe path %q", entry.Path)
+	}
+	if err := checkParents(root, rel); err != nil {
+		return err
+	}
+	partial := rel + partialSuffix
+
+	bag, err := os.OpenRoot(root)
+	if err != nil {
+		return err
+	}
+	defer bag.Close()
+
+	if st, err := lstatFile(bag, rel); err != nil {
+		return err
+	} else if st != nil {
+		if entry.Size >= 0 && st.Size() != entry.Size {
+			return fmt.Errorf("%s has %d bytes, fetch.txt declares %d", entry.Path, st.Size(), entry.Size)
+		}
+		return nil
+	}
+	if _, err := lstatFile(bag, partial); err != nil {
+		return err
+	}
+
+	if err := bag.MkdirAll(filepath.Dir(rel), 0o755); err != nil {
+		return err
+	}
+	f, err := bag.OpenFile(partial, os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if entry.Size >= 0 && offset > entry.Size {
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+		if offset, err = f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	}
+
+	size := offset
+	if entry.Size < 0 || offset < entry.Size {
+		rc, err := fetcher.Fetch(ctx, u, offset)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+
+		var r io.Reader = ctxReader{ctx: ctx, r: rc}
+		if entry.Size >= 0 {
+			// Read one extra byte to detect larger files.
+			r = io.LimitReader(r, entry.Size-offset+1)
+		}
+		n, err := io.Copy(f, r)
+		size += n
+		if err != nil {
+			return err
+		}
+	}
+
+	if entry.Size >= 0 && size != entry.Size {
+		f.Close()
+		bag.Remove(partial)
+		return fmt.Errorf("fetched %d bytes, fetch.txt declares %d", size, entry.Size)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return bag.Rename(partial, rel)
+}
+
+// lstatFile returns the file info of rel in root, or nil if it does not
+// exist. It returns an error if rel is a symbolic link, which a download would
+// follow.
+func lstatFile(root *os.Root, rel string) (os.FileInfo, error) {
+	fi, err := root.Lstat(rel)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if fi.Mode()&os.ModeSymlink != 0 {
+		return nil, fmt.Errorf("unsafe path %q: symbolic link", filepath.ToSlash(rel))
+	}
+
+	return fi, nil
+}
+
+// checkParents returns an error if a parent directory of rel in root is a
+// symbolic link, which could make a download write outside of the bag.
+func checkParents(root, rel string) error {
+	dir := ""
+	for name := range strings.SplitSeq(filepath.Dir(rel), string(filepath.Separator)) {
+		dir = filepath.Join(dir, name)
+		fi, err := os.Lstat(filepath.Join(root, dir))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("unsafe path %q: %s is a symbolic link", filepath.ToSlash(rel), filepath.ToSlash(dir))
+		}
+	}
+
+	return nil
+}
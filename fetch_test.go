@@ -0,0 +1,297 @@
+package bagit_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/artefactual-labs/bagit-gython"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+var holeyFiles = map[string]string{
+	"a.txt": "hello world",
+	"b.txt": "abcdef",
+	"c.txt": "bagit",
+}
+
+// makeHoleyBag makes a bag holding holeyFiles, then removes them from the
+// payload and lists them in fetch.txt with the URL returned by urlFor.
+func makeHoleyBag(t *testing.T, b *bagit.BagIt, urlFor func(name string) string) string {
+	t.Helper()
+
+	ops := []fs.PathOp{fs.WithFile("keep.txt", "local")}
+	for name, body := range holeyFiles {
+		ops = append(ops, fs.WithFile(name, body))
+	}
+	dir := fs.NewDir(t, "", fs.WithDir("bag", ops...))
+	path := dir.Join("bag")
+	_, err := b.Make(path, bagit.MakeOptions{})
+	assert.NilError(t, err)
+
+	var fetch strings.Builder
+	for name, body := range holeyFiles {
+		assert.NilError(t, os.Remove(filepath.Join(path, "data", name)))
+		fmt.Fprintf(&fetch, "%s %d data/%s\n", urlFor(name), len(body), name)
+	}
+	assert.NilError(t, os.WriteFile(filepath.Join(path, "fetch.txt"), []byte(fetch.String()), 0o644))
+
+	return path
+}
+
+func serveHoleyFiles(t *testing.T, handler func(w http.ResponseWriter, r *http.Request)) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if handler != nil {
+			handler(w, r)
+		}
+		body, ok := holeyFiles[strings.TrimPrefix(r.URL.Path, "/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, r.URL.Path, time.Time{}, strings.NewReader(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestComplete(t *testing.T) {
+	t.Parallel()
+
+	b := setUp(t)
+
+	t.Run("Fetches files over HTTP", func(t *testing.T) {
+		srv := serveHoleyFiles(t, nil)
+		path := makeHoleyBag(t, b, func(name string) string { return srv.URL + "/" + name })
+		assert.ErrorIs(t, b.Validate(path), bagit.ErrInvalid)
+
+		assert.NilError(t, b.Complete(path, nil, bagit.CompleteOptions{}))
+
+		for name, body := range holeyFiles {
+			blob, err := os.ReadFile(filepath.Join(path, "data", name))
+			assert.NilError(t, err)
+			assert.Equal(t, string(blob), body)
+		}
+		assert.NilError(t, b.Validate(path))
+	})
+
+	t.Run("Fetches files from the file system", func(t *testing.T) {
+		ops := []fs.PathOp{}
+		for name, body := range holeyFiles {
+			ops = append(ops, fs.WithFile(name, body))
+		}
+		src := fs.NewDir(t, "", ops...)
+		path := makeHoleyBag(t, b, func(name string) string {
+			return (&url.URL{Scheme: "file", Path: filepath.ToSlash(src.Join(name))}).String()
+		})
+
+		// File URLs are only fetched from a trusted directory.
+		err := b.Complete(path, nil, bagit.CompleteOptions{})
+		assert.ErrorContains(t, err, `unsupported URL scheme: "file"`)
+		err = b.Complete(path, bagit.Fetchers{"file": bagit.FileFetcher{Root: t.TempDir()}}, bagit.CompleteOptions{})
+		assert.ErrorContains(t, err, "file URL outside of")
+		for name := range holeyFiles {
+			_, err := os.Stat(filepath.Join(path, "data", name))
+			assert.Assert(t, os.IsNotExist(err))
+		}
+
+		fetcher := bagit.Fetchers{"file": bagit.FileFetcher{Root: src.Path()}}
+		assert.NilError(t, b.Complete(path, fetcher, bagit.CompleteOptions{}))
+	})
+
+	t.Run("Skips existing files and resumes partial downloads", func(t *testing.T) {
+		var mu sync.Mutex
+		requests := map[string]string{}
+		srv := serveHoleyFiles(t, func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			requests[r.URL.Path] = r.Header.Get("Range")
+		})
+		path := makeHoleyBag(t, b, func(name string) string { return srv.URL + "/" + name })
+		assert.NilError(t, os.WriteFile(filepath.Join(path, "data", "a.txt"), []byte("hello world"), 0o644))
+		assert.NilError(t, os.WriteFile(filepath.Join(path, "data", "b.txt.part"), []byte("abc"), 0o644))
+
+		assert.NilError(t, b.Complete(path, nil, bagit.CompleteOptions{}))
+		assert.DeepEqual(t, requests, map[string]string{"/b.txt": "bytes=3-", "/c.txt": ""})
+
+		_, err := os.Stat(filepath.Join(path, "data", "b.txt.part"))
+		assert.Assert(t, os.IsNotExist(err))
+	})
+
+	t.Run("Limits concurrent downloads", func(t *testing.T) {
+		var inFlight, peak atomic.Int32
+		srv := serveHoleyFiles(t, func(w http.ResponseWriter, r *http.Request) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+		})
+		path := makeHoleyBag(t, b, func(name string) string { return srv.URL + "/" + name })
+
+		assert.NilError(t, b.Complete(path, nil, bagit.CompleteOptions{Concurrency: 1}))
+		assert.Equal(t, peak.Load(), int32(1))
+	})
+
+	t.Run("Reports size mismatches", func(t *testing.T) {
+		srv := serveHoleyFiles(t, nil)
+		path := makeHoleyBag(t, b, func(name string) string { return srv.URL + "/" + name })
+		fetch := filepath.Join(path, "fetch.txt")
+		blob, err := os.ReadFile(fetch)
+		assert.NilError(t, err)
+		blob = []byte(strings.Replace(string(blob), " 6 data/b.txt", " 4 data/b.txt", 1))
+		assert.NilError(t, os.WriteFile(fetch, blob, 0o644))
+
+		err = b.Complete(path, nil, bagit.CompleteOptions{})
+		assert.ErrorContains(t, err, "fetched 5 bytes, fetch.txt declares 4")
+
+		_, err = os.Stat(filepath.Join(path, "data", "b.txt.part"))
+		assert.Assert(t, os.IsNotExist(err))
+	})
+
+	t.Run("Reports fetch errors", func(t *testing.T) {
+		srv := serveHoleyFiles(t, nil)
+		path := makeHoleyBag(t, b, func(name string) string { return srv.URL + "/missing/" + name })
+
+		err := b.Complete(path, nil, bagit.CompleteOptions{})
+		assert.ErrorContains(t, err, "complete: fetch "+srv.URL+"/missing/")
+		assert.ErrorContains(t, err, "unexpected status: 404 Not Found")
+	})
+
+	t.Run("Rejects unsupported schemes", func(t *testing.T) {
+		path := makeHoleyBag(t, b, func(name string) string { return "ftp://example.com/" + name })
+
+		err := b.Complete(path, nil, bagit.CompleteOptions{})
+		assert.ErrorContains(t, err, `unsupported URL scheme: "ftp"`)
+	})
+
+	t.Run("Completes unsized downloads", func(t *testing.T) {
+		srv := serveHoleyFiles(t, nil)
+		path := makeHoleyBag(t, b, func(name string) string { return srv.URL + "/" + name })
+		fetch := filepath.Join(path, "fetch.txt")
+		blob, err := os.ReadFile(fetch)
+		assert.NilError(t, err)
+		blob = []byte(strings.Replace(string(blob), " 6 data/b.txt", " - data/b.txt", 1))
+		assert.NilError(t, os.WriteFile(fetch, blob, 0o644))
+
+		// The server answers 416 to a range starting at the end of the file.
+		assert.NilError(t, os.WriteFile(filepath.Join(path, "data", "b.txt.part"), []byte("abcdef"), 0o644))
+
+		assert.NilError(t, b.Complete(path, nil, bagit.CompleteOptions{}))
+		blob, err = os.ReadFile(filepath.Join(path, "data", "b.txt"))
+		assert.NilError(t, err)
+		assert.Equal(t, string(blob), "abcdef")
+	})
+
+	t.Run("Rejects paths outside of the payload", func(t *testing.T) {
+		srv := serveHoleyFiles(t, nil)
+		path := makeHoleyBag(t, b, func(name string) string { return srv.URL + "/" + name })
+		fetch := filepath.Join(path, "fetch.txt")
+		blob, err := os.ReadFile(fetch)
+		assert.NilError(t, err)
+		blob = []byte(strings.Replace(string(blob), " data/b.txt", " b.txt", 1))
+		assert.NilError(t, os.WriteFile(fetch, blob, 0o644))
+
+		err = b.Complete(path, nil, bagit.CompleteOptions{})
+		assert.ErrorContains(t, err, `unsafe path "b.txt"`)
+		_, err = os.Stat(filepath.Join(path, "b.txt"))
+		assert.Assert(t, os.IsNotExist(err))
+	})
+
+	t.Run("Refuses symbolic links", func(t *testing.T) {
+		srv := serveHoleyFiles(t, nil)
+		path := makeHoleyBag(t, b, func(name string) string { return srv.URL + "/" + name })
+		fetch := filepath.Join(path, "fetch.txt")
+		blob, err := os.ReadFile(fetch)
+		assert.NilError(t, err)
+		blob = []byte(strings.Replace(string(blob), " data/b.txt", " data/sub/b.txt", 1))
+		assert.NilError(t, os.WriteFile(fetch, blob, 0o644))
+		// The link stays in the bag, but leads out of its payload.
+		if err := os.Symlink(path, filepath.Join(path, "data", "sub")); err != nil {
+			t.Skipf("symbolic links are not supported: %v", err)
+		}
+
+		err = b.Complete(path, nil, bagit.CompleteOptions{})
+		assert.ErrorContains(t, err, `unsafe path "data/sub/b.txt": data/sub is a symbolic link`)
+		_, err = os.Stat(filepath.Join(path, "b.txt"))
+		assert.Assert(t, os.IsNotExist(err))
+	})
+
+	t.Run("Refuses symbolic link files", func(t *testing.T) {
+		srv := serveHoleyFiles(t, nil)
+		outside := fs.NewDir(t, "", fs.WithFile("target.txt", "abc"))
+
+		for name, target := range map[string]string{
+			// bagit-python already refuses manifest paths leading out of the
+			// bag, but not a link to another file of the bag.
+			"b.txt":      "keep.txt",
+			"b.txt.part": outside.Join("target.txt"),
+		} {
+			path := makeHoleyBag(t, b, func(name string) string { return srv.URL + "/" + name })
+			if err := os.Symlink(target, filepath.Join(path, "data", name)); err != nil {
+				t.Skipf("symbolic links are not supported: %v", err)
+			}
+
+			err := b.Complete(path, nil, bagit.CompleteOptions{})
+			assert.ErrorContains(t, err, fmt.Sprintf("unsafe path %q: symbolic link", "data/"+name))
+			blob, err := os.ReadFile(filepath.Join(path, "data", "keep.txt"))
+			assert.NilError(t, err)
+			assert.Equal(t, string(blob), "local")
+			blob, err = os.ReadFile(outside.Join("target.txt"))
+			assert.NilError(t, err)
+			assert.Equal(t, string(blob), "abc")
+		}
+	})
+
+	t.Run("Stops when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		srv := serveHoleyFiles(t, func(w http.ResponseWriter, r *http.Request) {
+			cancel()
+		})
+		path := makeHoleyBag(t, b, func(name string) string { return srv.URL + "/" + name })
+
+		err := b.CompleteContext(ctx, path, nil, bagit.CompleteOptions{})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestValidatorComplete(t *testing.T) {
+	t.Parallel()
+
+	v, err := bagit.NewValidator(bagit.WithTempCacheDir())
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, v.Close())
+	})
+
+	// The downloads do not hold the only runner of the pool.
+	busy := make(chan error, len(holeyFiles))
+	srv := serveHoleyFiles(t, func(w http.ResponseWriter, r *http.Request) {
+		busy <- v.TryValidate("internal/testdata/valid-bag")
+	})
+	path := makeHoleyBag(t, setUp(t), func(name string) string { return srv.URL + "/" + name })
+
+	assert.NilError(t, v.Complete(path, nil, bagit.CompleteOptions{Concurrency: 1}))
+	close(busy)
+	for err := range busy {
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, v.Validate(path))
+}
@@ -0,0 +1,197 @@
+package bagit
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// WithWarmup starts the Python process of every runner of a Validator and
+// checks that it answers commands while bootstrapping, so that the first
+// commands do not pay for the start of the interpreter and the import of
+// bagit-python. NewValidator, or the first command with WithDeferredRuntime,
+// fails if a runner does not answer. A warm-up interrupted by the context of
+// that command is done again by the next command.
+//
+// Only the runners the pool starts with are warmed up, see WithPoolBounds.
+func WithWarmup() ValidatorOption {
+	return func(cfg *validatorConfig) {
+		cfg.warmup = true
+	}
+}
+
+// WithHealthCheck checks every interval that the idle runners of a Validator
+// answer commands within timeout. A runner that does not is stopped and
+// replaced by a new one, which starts its process on its first command.
+// Runners whose process has not been started yet are not checked.
+//
+// By default, runners are only checked by Validator.Health.
+func WithHealthCheck(interval, timeout time.Duration) ValidatorOption {
+	return func(cfg *validatorConfig) {
+		cfg.healthInterval = interval
+		cfg.healthTimeout = timeout
+	}
+}
+
+// Health reports whether v can run commands, e.g. for a readiness probe.
+//
+// It checks that an idle runner answers a command before ctx is done. A runner
+// that does not is replaced, and Health returns the error. Health starts the
+// embedded runtime with WithDeferredRuntime, and a runner when none is idle
+// and the pool can grow. If all runners are busy, Health returns nil without
+// waiting. It returns ErrClosed after Close.
+func (v *Validator) Health(ctx context.Context) error {
+	if v == nil {
+		return ErrClosed
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	v.mu.Lock()
+	closed := v.closed
+	v.mu.Unlock()
+	if closed {
+		return ErrClosed
+	}
+	if v.backend == BackendNative {
+		return nil
+	}
+
+	// Health checks do not count as busy runners in the metrics.
+	if ok := v.sem.TryAcquire(1); !ok {
+		return nil
+	}
+	defer v.sem.Release(1)
+
+	if err := v.ensureBootstrapped(ctx); err != nil {
+		return err
+	}
+
+	r, ok := v.takeIdle(nil)
+	if !ok {
+		b, err := v.take()
+		if err != nil {
+			return err
+		}
+		r = idleRunner{b: b, since: time.Now()}
+	}
+
+	if err := r.b.ping(ctx); err != nil {
+		v.recycle(r, err)
+		return fmt.Errorf("health: %w", err)
+	}
+	v.putIdle(r)
+
+	return nil
+}
+
+// warmUp starts the processes of the runners of pool and pings them.
+func warmUp(ctx context.Context, pool []*BagIt) error {
+	g, ctx := errgroup.WithContext(ctx)
+	for _, b := range pool {
+		g.Go(func() error {
+			return b.ping(ctx)
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return fmt.Errorf("warm up: %w", err)
+	}
+
+	return nil
+}
+
+// checkHealth pings the idle runners every health check interval until Close.
+func (v *Validator) checkHealth() {
+	defer v.background.Done()
+
+	ticker := time.NewTicker(v.healthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-v.done:
+			return
+		case <-ticker.C:
+			v.checkIdleRunners()
+		}
+	}
+}
+
+// checkIdleRunners pings the idle runners whose process has been started,
+// one at a time, while pool slots are available.
+func (v *Validator) checkIdleRunners() {
+	checked := map[*BagIt]bool{}
+	for {
+		if ok := v.sem.TryAcquire(1); !ok {
+			return
+		}
+
+		r, ok := v.takeIdle(func(b *BagIt) bool {
+			return !checked[b] && b.runner.starts > 0
+		})
+		if !ok {
+			v.sem.Release(1)
+			return
+		}
+		checked[r.b] = true
+
+		ctx, cancel := context.WithTimeout(context.Background(), v.healthTimeout)
+		err := r.b.ping(ctx)
+		cancel()
+		if err != nil {
+			v.recycle(r, err)
+		} else {
+			v.putIdle(r)
+		}
+		v.sem.Release(1)
+	}
+}
+
+// takeIdle removes the idle runner used last for which match, if not nil,
+// returns true from the idle runners.
+func (v *Validator) takeIdle(match func(*BagIt) bool) (idleRunner, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for i := len(v.idle) - 1; i >= 0; i-- {
+		r := v.idle[i]
+		if match == nil || match(r.b) {
+			v.idle = slices.Delete(v.idle, i, i+1)
+			return r, true
+		}
+	}
+
+	return idleRunner{}, false
+}
+
+// putIdle returns a runner taken by takeIdle to the idle runners, keeping the
+// time since it is idle so that checks do not delay WithIdleTimeout.
+func (v *Validator) putIdle(r idleRunner) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	i := slices.IndexFunc(v.idle, func(o idleRunner) bool {
+		return o.since.After(r.since)
+	})
+	if i < 0 {
+		i = len(v.idle)
+	}
+	v.idle = slices.Insert(v.idle, i, r)
+}
+
+// recycle stops the unresponsive runner r and replaces it by a new idle
+// runner.
+func (v *Validator) recycle(r idleRunner, err error) {
+	logger := r.b.runner.logger
+	logger.Warn("runner unresponsive", "err", err)
+	if err := r.b.Cleanup(); err != nil {
+		logger.Warn("stop unresponsive runner", "err", err)
+	}
+
+	v.putIdle(idleRunner{b: v.replace(r.b), since: r.since})
+	v.metrics.RunnerRestarted()
+}
@@ -0,0 +1,128 @@
+package bagit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestValidatorWarmup(t *testing.T) {
+	t.Parallel()
+
+	for _, warmup := range []bool{false, true} {
+		opts := []ValidatorOption{WithPoolSize(2), WithTempCacheDir()}
+		if warmup {
+			opts = append(opts, WithWarmup())
+		}
+		v, err := NewValidator(opts...)
+		assert.NilError(t, err)
+
+		v.mu.Lock()
+		for _, b := range v.pool {
+			assert.Equal(t, b.runner.running.Load(), warmup)
+		}
+		v.mu.Unlock()
+		assert.NilError(t, v.Close())
+	}
+}
+
+func TestValidatorWarmupCanceled(t *testing.T) {
+	t.Parallel()
+
+	v, err := NewValidator(WithDeferredRuntime(), WithWarmup(), WithTempCacheDir())
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, v.Close())
+	})
+
+	// A warm-up canceled by the caller does not fail the next commands.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, v.Health(ctx), context.Canceled)
+
+	assert.NilError(t, v.Health(context.Background()))
+	assert.NilError(t, v.Validate("internal/testdata/valid-bag"))
+}
+
+func TestValidatorHealth(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Starts a runner", func(t *testing.T) {
+		t.Parallel()
+
+		v, err := NewValidator(WithDeferredRuntime(), WithPoolBounds(0, 2), WithTempCacheDir())
+		assert.NilError(t, err)
+
+		assert.NilError(t, v.Health(context.Background()))
+		assert.DeepEqual(t, v.Stats(), PoolStats{MaxRunners: 2, Runners: 1, Idle: 1})
+
+		// The idle runner is checked again.
+		assert.NilError(t, v.Health(context.Background()))
+		assert.Equal(t, v.Stats().Runners, 1)
+
+		assert.NilError(t, v.Close())
+		assert.ErrorIs(t, v.Health(context.Background()), ErrClosed)
+	})
+
+	t.Run("Keeps the idle time of runners", func(t *testing.T) {
+		t.Parallel()
+
+		v, err := NewValidator(WithPoolBounds(0, 1), WithIdleTimeout(time.Hour), WithTempCacheDir())
+		assert.NilError(t, err)
+		t.Cleanup(func() {
+			assert.NilError(t, v.Close())
+		})
+
+		assert.NilError(t, v.Validate("internal/testdata/valid-bag"))
+		since := v.idle[0].since
+		assert.NilError(t, v.Health(context.Background()))
+		assert.Equal(t, v.idle[0].since, since)
+	})
+
+	t.Run("Does not wait for busy runners", func(t *testing.T) {
+		t.Parallel()
+
+		v, err := NewValidator(WithPoolSize(1), WithTempCacheDir())
+		assert.NilError(t, err)
+		t.Cleanup(func() {
+			assert.NilError(t, v.Close())
+		})
+
+		assert.NilError(t, v.sem.Acquire(context.Background(), 1))
+		defer v.sem.Release(1)
+
+		assert.NilError(t, v.Health(context.Background()))
+	})
+
+	t.Run("Replaces a failing runner", func(t *testing.T) {
+		t.Parallel()
+
+		v, err := NewValidator(WithPoolSize(1), WithTempCacheDir())
+		assert.NilError(t, err)
+		t.Cleanup(func() {
+			assert.NilError(t, v.Close())
+		})
+		old := v.pool[0]
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.ErrorIs(t, v.Health(ctx), context.Canceled)
+
+		assert.Assert(t, v.pool[0] != old)
+		assert.Assert(t, old.runner == nil)
+		assert.DeepEqual(t, v.Stats(), PoolStats{MinRunners: 1, MaxRunners: 1, Runners: 1, Idle: 1})
+		assert.NilError(t, v.Health(context.Background()))
+	})
+
+	t.Run("Native backend", func(t *testing.T) {
+		t.Parallel()
+
+		v, err := NewValidator(WithBackend(BackendNative))
+		assert.NilError(t, err)
+		assert.NilError(t, v.Health(context.Background()))
+		assert.NilError(t, v.Close())
+		assert.ErrorIs(t, v.Health(context.Background()), ErrClosed)
+	})
+}
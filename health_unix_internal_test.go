@@ -0,0 +1,76 @@
+//go:build unix
+
+package bagit
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/poll"
+)
+
+// pauseRunner stops the process of the only runner of v, which then no longer
+// answers commands.
+func pauseRunner(t *testing.T, v *Validator) *BagIt {
+	t.Helper()
+
+	v.mu.Lock()
+	b := v.pool[0]
+	v.mu.Unlock()
+	assert.Assert(t, b.runner.running.Load())
+	assert.NilError(t, b.runner.cmd.Process.Signal(syscall.SIGSTOP))
+
+	return b
+}
+
+func TestValidatorHealthReplacesUnresponsiveRunner(t *testing.T) {
+	t.Parallel()
+
+	v, err := NewValidator(WithPoolSize(1), WithWarmup(), WithTempCacheDir())
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, v.Close())
+	})
+	old := pauseRunner(t, v)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, v.Health(ctx), context.DeadlineExceeded)
+
+	v.mu.Lock()
+	replaced := v.pool[0] != old
+	v.mu.Unlock()
+	assert.Assert(t, replaced)
+	assert.NilError(t, v.Health(context.Background()))
+	assert.NilError(t, v.Validate("internal/testdata/valid-bag"))
+}
+
+func TestValidatorHealthCheckReplacesUnresponsiveRunner(t *testing.T) {
+	t.Parallel()
+
+	v, err := NewValidator(
+		WithPoolSize(1),
+		WithWarmup(),
+		WithHealthCheck(50*time.Millisecond, 200*time.Millisecond),
+		WithTempCacheDir(),
+	)
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, v.Close())
+	})
+	old := pauseRunner(t, v)
+
+	poll.WaitOn(t, func(poll.LogT) poll.Result {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		if v.pool[0] == old {
+			return poll.Continue("runner not replaced")
+		}
+		return poll.Success()
+	}, poll.WithTimeout(5*time.Second))
+
+	assert.NilError(t, v.Validate("internal/testdata/valid-bag"))
+}
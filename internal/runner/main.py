@@ -1,16 +1,31 @@
+import contextlib
+import functools
 import json
+import logging
 import multiprocessing
+import os
 import sys
+import time
+import traceback
 from dataclasses import dataclass, field
-from typing import Any, Dict
+from typing import Any, Dict, Optional
 
-from bagit import Bag, make_bag
+import bagit
+from bagit import (
+    CHECKSUM_ALGOS,
+    BagError,
+    BagValidationError,
+    ChecksumMismatch,
+    FileNormalizationConflict,
+    make_bag,
+)
 
 
 @dataclass
 class Command:
     name: str
     args: Dict[str, Any] = field(default_factory=dict)
+    trace: Optional[Dict[str, str]] = None
 
 
 class UnknownCommandError(Exception):
@@ -21,28 +36,90 @@ class ExitError(Exception):
     pass
 
 
+class Bag(bagit.Bag):
+    """A bagit-python Bag reporting malformed lines of fetch.txt with a
+    BagError, like other invalid bags, instead of failing to unpack them with a
+    ValueError."""
+
+    def fetch_entries(self):
+        fetch_file_path = os.path.join(self.path, "fetch.txt")
+        if os.path.isfile(fetch_file_path):
+            with bagit.open_text_file(
+                fetch_file_path, "r", encoding=self.encoding
+            ) as fetch_file:
+                for line in fetch_file:
+                    if len(line.strip().split(None, 2)) != 3:
+                        raise BagError(
+                            f'Malformed line in "{fetch_file_path}": {line.strip()}'
+                        )
+        yield from super().fetch_entries()
+
+
+class JSONLogHandler(logging.Handler):
+    """Write logging records to stderr as JSON lines, which the Go side turns
+    into slog records. The path of the bag being processed is added to every
+    record."""
+
+    path = None
+
+    def emit(self, record):
+        try:
+            entry = {
+                "level": record.levelname,
+                "logger": record.name,
+                "message": record.getMessage(),
+            }
+            if JSONLogHandler.path:
+                entry["path"] = JSONLogHandler.path
+            if record.exc_info:
+                entry["exception"] = "".join(
+                    traceback.format_exception(*record.exc_info)
+                ).rstrip()
+            sys.stderr.write(json.dumps(entry) + "\n")
+            sys.stderr.flush()
+        except Exception:
+            self.handleError(record)
+
+
+def configure_logging():
+    level = os.environ.get("BAGIT_RUNNER_LOG_LEVEL")
+    if not level:
+        return
+    root = logging.getLogger()
+    root.addHandler(JSONLogHandler())
+    root.setLevel(level)
+    logging.captureWarnings(True)
+
+
 class Runner:
-    ALLOWED_COMMANDS = ("validate", "make", "exit")
+    ALLOWED_COMMANDS = ("validate", "make", "inspect", "update", "ping", "exit")
     ALLOWED_COMMANDS_LIST = ", ".join(ALLOWED_COMMANDS)
 
     def __init__(self, cmd, stdout):
         self.cmd = cmd
         self.stdout = stdout
+        self.spans = Spans(stdout, cmd.trace)
 
     def run(self):
         name = self.cmd.name
         args = self.cmd.args
 
         resp = {}
+        JSONLogHandler.path = (args or {}).get("path")
+        self.spans.start("handle")
         try:
             ret = self.get_handler(name)(args)
             resp.update(ret)
         except ExitError:
             raise
         except BaseException as err:
+            self.spans.end(error=str(err))
             self.write_error(self.stdout, err)
             return
+        finally:
+            JSONLogHandler.path = None
 
+        self.spans.end()
         self.write(self.stdout, resp)
 
     def get_handler(self, name):
@@ -57,13 +134,140 @@ class Runner:
 
     def validate_handler(self, args):
         bag = Bag(args.get("path"))
-        bag.validate(processes=multiprocessing.cpu_count())
-        return {"valid": True}
+        processes = args.get("processes") or multiprocessing.cpu_count()
+        fast = args.get("fast", False)
+        completeness_only = args.get("completeness_only", False)
+        progress = None
+        if args.get("progress"):
+            progress = Progress(self.stdout)
+        elif not self.spans.enabled:
+            bag.validate(
+                processes=processes, fast=fast, completeness_only=completeness_only
+            )
+            return self.validated(bag, args)
+
+        # Same steps as Bag.validate, reporting the current phase as a progress
+        # line and a span, if enabled.
+        require_internals()
+
+        def start(phase, paths=()):
+            self.spans.phase(phase)
+            if progress is not None:
+                progress.start(phase, paths)
+
+        start("structure")
+        bag._validate_structure()
+        bag._validate_bagittxt()
+        bag.validate_fetch()
+        if fast and not bag.has_oxum():
+            raise BagValidationError(
+                "Fast validation requires bag-info.txt to include Payload-Oxum"
+            )
+        start("oxum")
+        bag._validate_oxum()
+        if fast:
+            return {"valid": True}
+        start("completeness")
+        bag._validate_completeness()
+        if completeness_only:
+            return {"valid": True}
+        paths = ()
+        if progress is not None:
+            paths = [bag_file(bag, p) for p in bag.entries]
+        start("fixity", paths)
+        with reporting_hashes(progress, bag.path):
+            bag._validate_entries(processes)
+        return self.validated(bag, args)
+
+    @staticmethod
+    def validated(bag, args):
+        """Respond to a successful validation, with the bytes hashed if they
+        were requested and fixity was checked."""
+        resp = {"valid": True}
+        if args.get("bytes_hashed") and not (
+            args.get("fast") or args.get("completeness_only")
+        ):
+            resp["bytes_hashed"] = bytes_hashed(bag)
+        return resp
 
     def make_handler(self, args):
         bag_dir = args.pop("path")
-        bag = make_bag(bag_dir, **args)
-        return {"version": bag.version}
+        if "bag_info" in args:
+            args["bag_info"] = tag_dict(args["bag_info"])
+        for alg in args.get("checksums") or []:
+            if alg not in CHECKSUM_ALGOS:
+                raise BagError(f"Unsupported checksum algorithm: {alg}")
+        progress = None
+        if args.pop("progress", False):
+            require_internals()
+            progress = Progress(self.stdout)
+        try:
+            with reporting_hashes(progress, bag_dir):
+                bag = make_bag(bag_dir, **args)
+        except RuntimeError as err:
+            # make_bag reports unusable bag directories with RuntimeError.
+            raise BagError(str(err)) from err
+        return {
+            "version": bag.version,
+            "payload_oxum": bag.info.get("Payload-Oxum"),
+        }
+
+    def inspect_handler(self, args):
+        bag = Bag(args.get("path"))
+        oxum = bag.info.get("Payload-Oxum")
+        if isinstance(oxum, list):
+            oxum = oxum[0]
+        return {
+            "path": bag.path,
+            "version": bag.version,
+            "encoding": bag.encoding,
+            "bag_info": bag_info_tags(bag),
+            "algorithms": sorted(bag.algorithms),
+            "payload_oxum": oxum,
+            "manifests": manifest_entries(bag.payload_entries()),
+            "tag_manifests": manifest_entries(bag.tagfile_entries()),
+            "fetch": [
+                {"url": url, "size": int(size) if size.isdigit() else -1, "path": path}
+                for url, size, path in bag.fetch_entries()
+            ],
+            "missing_optional_tagfiles": list(bag.missing_optional_tagfiles()),
+        }
+
+    def update_handler(self, args):
+        bag = Bag(args.get("path"))
+        manifests = args.get("manifests", False)
+        if not manifests:
+            # Without new manifests, refuse to update a bag whose payload files
+            # do not match them or the recorded Payload-Oxum, which is kept.
+            require_internals()
+            bag._validate_structure()
+            bag._validate_completeness()
+            bag._validate_oxum()
+            oxum = bag.info.get("Payload-Oxum") or payload_oxum(bag)
+
+        for label in args.get("remove") or []:
+            bag.info.pop(label, None)
+        bag.info.update(tag_dict(args.get("set") or []))
+        for tag in args.get("add") or []:
+            add_tag(bag.info, tag["label"], tag["value"])
+        if not manifests:
+            bag.info["Payload-Oxum"] = oxum
+
+        progress = None
+        if args.get("progress"):
+            require_internals()
+            progress = Progress(self.stdout)
+        cwd = os.getcwd()
+        try:
+            with reporting_hashes(progress, bag.path):
+                bag.save(processes=args.get("processes") or 1, manifests=manifests)
+        finally:
+            # Bag.save does not restore the working directory on errors.
+            os.chdir(cwd)
+        return {"payload_oxum": bag.info.get("Payload-Oxum")}
+
+    def ping_handler(self, args):
+        return {"pong": True}
 
     def exit_handler(self, args):
         raise ExitError
@@ -74,10 +278,339 @@ class Runner:
 
     @staticmethod
     def write_error(stdout, err):
-        Runner.write(stdout, {"err": str(err), "type": err.__class__.__name__})
+        resp = {
+            "err": str(err),
+            "type": err.__class__.__name__,
+            "kind": "bag" if isinstance(err, BagError) else "runner",
+        }
+        details = Runner.error_details(err)
+        if details:
+            resp["details"] = details
+        Runner.write(stdout, resp)
+
+    @staticmethod
+    def error_details(err):
+        if isinstance(err, FileNormalizationConflict):
+            return [Runner.error_detail(err)]
+        return [Runner.error_detail(d) for d in getattr(err, "details", None) or []]
+
+    @staticmethod
+    def error_detail(detail):
+        ret = {"type": detail.__class__.__name__, "message": str(detail)}
+        if isinstance(detail, FileNormalizationConflict):
+            ret["path"] = detail.file_a
+            ret["conflicting_path"] = detail.file_b
+            return ret
+        ret["path"] = getattr(detail, "path", "")
+        if isinstance(detail, ChecksumMismatch):
+            ret["algorithm"] = detail.algorithm
+            ret["expected"] = detail.expected
+            ret["found"] = detail.found
+        return ret
+
+
+class Progress:
+    """Report the progress of a command as interim JSON lines written before
+    its response. File reports are throttled, except for the last file of a
+    phase."""
+
+    INTERVAL = 0.1
+
+    def __init__(self, stdout):
+        self.stdout = stdout
+        self.phase = None
+        self.files_done = self.files_total = 0
+        self.bytes_done = self.bytes_total = 0
+        self.path = ""
+        self.last = 0.0
+
+    def start(self, phase, paths=()):
+        self.phase = phase
+        self.files_done = self.bytes_done = 0
+        self.files_total = len(paths)
+        self.bytes_total = sum(file_size(p) for p in paths)
+        self.path = ""
+        self.report(force=True)
+
+    def file_done(self, path, size):
+        self.files_done += 1
+        self.bytes_done += size
+        self.path = path
+        self.report(force=self.files_done == self.files_total)
+
+    def report(self, force=False):
+        now = time.monotonic()
+        if not force and now - self.last < self.INTERVAL:
+            return
+        self.last = now
+        Runner.write(
+            self.stdout,
+            {
+                "progress": {
+                    "phase": self.phase,
+                    "files_done": self.files_done,
+                    "files_total": self.files_total,
+                    "bytes_done": self.bytes_done,
+                    "bytes_total": self.bytes_total,
+                    "path": self.path,
+                }
+            },
+        )
+
+
+class Spans:
+    """Time the handling of a command and its phases, and report them as
+    interim JSON lines written before its response. The Go side records them
+    as child spans of the span whose W3C trace context came with the command.
+    Disabled when the command has no trace context."""
+
+    def __init__(self, stdout, trace):
+        self.stdout = stdout
+        self.traceparent = (trace or {}).get("traceparent")
+        self.current = None
+        self.current_phase = None
+
+    @property
+    def enabled(self):
+        return bool(self.traceparent)
+
+    def start(self, name):
+        """End the current spans, then start a new one for the command."""
+        self.end()
+        if self.enabled:
+            self.current = (name, time.time_ns())
+
+    def phase(self, name):
+        """End the current phase, then start a new one within the command."""
+        self.write(self.current_phase)
+        self.current_phase = None
+        if self.enabled:
+            self.current_phase = (name, time.time_ns())
+
+    def end(self, error=None):
+        """End the current phase and command, both failed if error is set."""
+        self.write(self.current_phase, error)
+        self.write(self.current, error)
+        self.current = self.current_phase = None
+
+    def write(self, current, error=None):
+        if current is None:
+            return
+        name, start = current
+        span = {
+            "name": name,
+            "traceparent": self.traceparent,
+            "start": start,
+            "end": time.time_ns(),
+        }
+        if error:
+            span["error"] = error
+        Runner.write(self.stdout, {"span": span})
+
+
+# Private functions of bagit-python used to report progress and phases, and to
+# check a bag before updating it. They are those of the commit pinned in
+# internal/dist/requirements.txt and must be checked when upgrading it, as
+# validate_handler reimplements Bag.validate with them.
+INTERNALS = (
+    "_calc_hashes",
+    "_multiprocessing_pool_map",
+    "_walk",
+    "generate_manifest_lines",
+    "make_manifests",
+)
+BAG_INTERNALS = (
+    "_validate_structure",
+    "_validate_bagittxt",
+    "_validate_oxum",
+    "_validate_completeness",
+    "_validate_entries",
+)
+
+
+def require_internals():
+    """Fail a command early if bagit-python lacks an internal function it
+    relies on, instead of misreporting it or failing with an AttributeError."""
+    missing = [name for name in INTERNALS if not hasattr(bagit, name)]
+    missing += [f"Bag.{name}" for name in BAG_INTERNALS if not hasattr(Bag, name)]
+    if missing:
+        raise RuntimeError(
+            "this bagit-python version lacks internal functions used by the runner, "
+            "missing: " + ", ".join(missing)
+        )
+
+
+def file_size(path):
+    try:
+        return os.path.getsize(path)
+    except OSError:
+        return 0
+
+
+@contextlib.contextmanager
+def reporting_hashes(progress, base_path):
+    """Report every file hashed by bagit-python while validating fixity or
+    making manifests.
+
+    The bagit-python hashing functions are replaced in this process only.
+    Results computed by multiprocessing workers are reported by pool_map as
+    they are received."""
+    if progress is None:
+        yield
+        return
+
+    def calc_hashes_done(result):
+        rel_path = result[0]
+        progress.file_done(rel_path, file_size(os.path.join(base_path, rel_path)))
+
+    def manifest_lines_done(result):
+        _, _, filename, size = result[0]
+        progress.file_done(filename, size)
+
+    def make_manifests(data_dir, *args, **kwargs):
+        progress.start("manifests", list(bagit._walk(data_dir)))
+        return original["make_manifests"](data_dir, *args, **kwargs)
+
+    def pool_map(func, iterable, processes, initializer=None):
+        report = None
+        if isinstance(func, functools.partial) and hasattr(func.func, "report"):
+            report = func.func.report
+            func = functools.partial(func.func.__wrapped__, *func.args, **func.keywords)
+        elif hasattr(func, "report"):
+            report = func.report
+            func = func.__wrapped__
+
+        # Workers must run the original functions, which are pickled by name.
+        restore(original)
+        pool = multiprocessing.Pool(processes=processes, initializer=initializer)
+        try:
+            results = []
+            for result in pool.imap(func, iterable):
+                if report is not None:
+                    report(result)
+                results.append(result)
+        except BaseException:
+            pool.terminate()
+            raise
+        else:
+            pool.close()
+            return results
+        finally:
+            pool.join()
+            restore(patched)
+
+    original = {
+        "_calc_hashes": bagit._calc_hashes,
+        "generate_manifest_lines": bagit.generate_manifest_lines,
+        "make_manifests": bagit.make_manifests,
+        "_multiprocessing_pool_map": bagit._multiprocessing_pool_map,
+    }
+    patched = {
+        "_calc_hashes": reporting(bagit._calc_hashes, calc_hashes_done),
+        "generate_manifest_lines": reporting(
+            bagit.generate_manifest_lines, manifest_lines_done
+        ),
+        "make_manifests": make_manifests,
+        "_multiprocessing_pool_map": pool_map,
+    }
+    restore(patched)
+    try:
+        yield
+    finally:
+        restore(original)
+
+
+def restore(funcs):
+    for name, func in funcs.items():
+        setattr(bagit, name, func)
+
+
+def reporting(func, report):
+    """Wrap func so that report is called with every result."""
+
+    @functools.wraps(func)
+    def wrapper(*args, **kwargs):
+        result = func(*args, **kwargs)
+        report(result)
+        return result
+
+    wrapper.report = report
+    return wrapper
+
+
+def tag_dict(tags):
+    """Convert a list of label/value pairs into the dict used by bagit-python,
+    where repeated labels hold a list of values."""
+    ret = {}
+    for tag in tags:
+        ret.setdefault(tag["label"], []).append(tag["value"])
+    return {k: v[0] if len(v) == 1 else v for k, v in ret.items()}
+
+
+def add_tag(info, label, value):
+    """Append a value to a bagit-python tag dict, keeping existing values."""
+    if label not in info:
+        info[label] = value
+    elif isinstance(info[label], list):
+        info[label].append(value)
+    else:
+        info[label] = [info[label], value]
+
+
+def payload_oxum(bag):
+    """Compute the Payload-Oxum of the payload files, as Bag._validate_oxum."""
+    total_bytes = total_files = 0
+    for payload_file in bag.payload_files():
+        total_bytes += os.stat(os.path.join(bag.path, payload_file)).st_size
+        total_files += 1
+    return "%d.%d" % (total_bytes, total_files)
+
+
+def bytes_hashed(bag):
+    """Sum the sizes of the files hashed by a successful Bag.validate: the
+    payload files, whose total the validated Payload-Oxum records if present,
+    and the tag files listed in the tag manifests."""
+    oxum = bag.info.get("Payload-Oxum")
+    if isinstance(oxum, list):
+        oxum = oxum[0]
+    if oxum:
+        total = int(oxum.split(".", 1)[0])
+    else:
+        total = sum(file_size(bag_file(bag, p)) for p in bag.payload_entries())
+    return total + sum(file_size(bag_file(bag, p)) for p in bag.tagfile_entries())
+
+
+def bag_file(bag, path):
+    """Return the filesystem path of a file listed in the manifests."""
+    return os.path.join(bag.path, bag.normalized_filesystem_names.get(path, path))
+
+
+def bag_info_tags(bag):
+    """Read the tags of bag-info.txt in file order. Bag.info groups the values
+    of repeated labels, losing their order relative to other labels."""
+    path = os.path.join(bag.path, bag.tag_file_name)
+    if not os.path.exists(path):
+        return []
+    with bagit.open_text_file(path, "r", encoding=bag.encoding) as tag_file:
+        return [
+            {"label": label, "value": value}
+            for label, value in bagit._parse_tags(tag_file)
+        ]
+
+
+def manifest_entries(entries):
+    """Convert bagit-python entries, which map paths to their checksum per
+    algorithm, into lists of path/checksum pairs per algorithm."""
+    ret = {}
+    for path in sorted(entries):
+        for alg, checksum in entries[path].items():
+            ret.setdefault(alg, []).append({"path": path, "checksum": checksum})
+    return ret
 
 
 def main():
+    configure_logging()
+
     while True:
         line = sys.stdin.readline()
         if not line:
@@ -89,7 +622,11 @@ def main():
             Runner.write_error(sys.stdout, err)
             continue
 
-        cmd = Command(name=payload.get("name"), args=payload.get("args"))
+        cmd = Command(
+            name=payload.get("name"),
+            args=payload.get("args"),
+            trace=payload.get("trace"),
+        )
 
         runner = Runner(cmd, sys.stdout)
         try:
@@ -0,0 +1,104 @@
+package bagit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+)
+
+// logWriter is an io.Writer that logs the lines written to the standard error
+// of a runner process.
+//
+// Lines holding a JSON-encoded Python logging record are logged with the
+// record level, logger name, message and bag path. Other lines, e.g. Python
+// tracebacks or warnings printed by multiprocessing workers, are logged as
+// warnings.
+type logWriter struct {
+	logger  *slog.Logger
+	mu      sync.Mutex
+	partial []byte
+}
+
+// logRecord is a Python logging record written by the runner.
+type logRecord struct {
+	Level     string `json:"level"`
+	Logger    string `json:"logger"`
+	Message   string `json:"message"`
+	Path      string `json:"path"`
+	Exception string `json:"exception"`
+}
+
+func newLogWriter(logger *slog.Logger) *logWriter {
+	return &logWriter{logger: logger}
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		w.log(bytes.TrimRight(w.partial[:i], "\r"))
+		w.partial = w.partial[i+1:]
+	}
+
+	return len(p), nil
+}
+
+func (w *logWriter) log(line []byte) {
+	if len(bytes.TrimSpace(line)) == 0 {
+		return
+	}
+
+	var rec logRecord
+	if line[0] != '{' || json.Unmarshal(line, &rec) != nil || rec.Logger == "" {
+		w.logger.LogAttrs(context.Background(), slog.LevelWarn, string(line), slog.String("logger", "stderr"))
+		return
+	}
+
+	attrs := []slog.Attr{slog.String("logger", rec.Logger)}
+	if rec.Path != "" {
+		attrs = append(attrs, slog.String("path", rec.Path))
+	}
+	if rec.Exception != "" {
+		attrs = append(attrs, slog.String("exception", rec.Exception))
+	}
+
+	w.logger.LogAttrs(context.Background(), slogLevel(rec.Level), rec.Message, attrs...)
+}
+
+// slogLevel maps a Python logging level name to a slog level.
+func slogLevel(level string) slog.Level {
+	switch level {
+	case "DEBUG":
+		return slog.LevelDebug
+	case "INFO":
+		return slog.LevelInfo
+	case "WARNING":
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+// pythonLogLevel returns the lowest Python logging level enabled by logger, so
+// that the runner does not emit records that would be discarded.
+func pythonLogLevel(logger *slog.Logger) string {
+	ctx := context.Background()
+	switch {
+	case logger.Enabled(ctx, slog.LevelDebug):
+		return "DEBUG"
+	case logger.Enabled(ctx, slog.LevelInfo):
+		return "INFO"
+	case logger.Enabled(ctx, slog.LevelWarn):
+		return "WARNING"
+	default:
+		return "ERROR"
+	}
+}
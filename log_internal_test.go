@@ -0,0 +1,47 @@
+package bagit
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestLogWriter(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	w := newLogWriter(logger)
+	_, _ = w.Write([]byte(`{"level": "DEBUG", "logger": "bagit", "message": "Loading manifest", "path": "/bag"}` + "\n"))
+	_, _ = w.Write([]byte(`{"level": "CRITICAL", "logger": "bagit", "mess`))
+	_, _ = w.Write([]byte(`age": "boom", "exception": "Traceback"}` + "\r\n\n"))
+	_, _ = w.Write([]byte("RuntimeError: boom\n"))
+	_, _ = w.Write([]byte("unterminated"))
+
+	assert.Equal(t, buf.String(), `level=DEBUG msg="Loading manifest" logger=bagit path=/bag
+level=ERROR msg=boom logger=bagit exception=Traceback
+level=WARN msg="RuntimeError: boom" logger=stderr
+`)
+}
+
+func TestPythonLogLevel(t *testing.T) {
+	for level, want := range map[slog.Level]string{
+		slog.LevelDebug:     "DEBUG",
+		slog.LevelInfo:      "INFO",
+		slog.LevelWarn:      "WARNING",
+		slog.LevelError:     "ERROR",
+		slog.LevelError + 4: "ERROR",
+	} {
+		logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, &slog.HandlerOptions{Level: level}))
+		assert.Equal(t, pythonLogLevel(logger), want)
+	}
+}
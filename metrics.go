@@ -0,0 +1,128 @@
+package bagit
+
+import (
+	"errors"
+	"time"
+)
+
+// ValidationOutcome classifies a finished validation reported to Metrics.
+type ValidationOutcome string
+
+const (
+	// OutcomeValid is a bag that passed validation.
+	OutcomeValid ValidationOutcome = "valid"
+
+	// OutcomeInvalid is a bag that failed validation, see ErrInvalid.
+	OutcomeInvalid ValidationOutcome = "invalid"
+
+	// OutcomeError is a validation that could not complete, e.g. a runner
+	// crash, a timeout or a canceled context.
+	OutcomeError ValidationOutcome = "error"
+
+	// OutcomeBusy is a TryValidate call rejected with ErrBusy.
+	OutcomeBusy ValidationOutcome = "busy"
+
+	// OutcomeClosed is a call rejected with ErrClosed.
+	OutcomeClosed ValidationOutcome = "closed"
+)
+
+func validationOutcome(err error) ValidationOutcome {
+	switch {
+	case err == nil:
+		return OutcomeValid
+	case errors.Is(err, ErrInvalid):
+		return OutcomeInvalid
+	case errors.Is(err, ErrBusy):
+		return OutcomeBusy
+	case errors.Is(err, ErrClosed):
+		return OutcomeClosed
+	default:
+		return OutcomeError
+	}
+}
+
+// Metrics receives measurements of a Validator, see WithMetrics. Its methods
+// are called concurrently by the goroutines running commands and should return
+// quickly. Package bagitprom implements Metrics with Prometheus collectors.
+type Metrics interface {
+	// PoolSize reports the maximum number of runners of the pool when the
+	// Validator is created, see Validator.PoolSize.
+	PoolSize(n int)
+
+	// Runners reports the number of runners in the pool whenever it changes,
+	// see WithPoolBounds. The pool has no runners with BackendNative.
+	Runners(n int)
+
+	// RunnersBusy reports the number of runners executing a command whenever
+	// it changes, or of validations in progress with BackendNative.
+	RunnersBusy(n int)
+
+	// AcquireWait reports how long a command waited for an available runner.
+	AcquireWait(d time.Duration)
+
+	// Validation reports the outcome and duration of a validation, including
+	// the wait for a runner.
+	Validation(outcome ValidationOutcome, d time.Duration)
+
+	// RunnerRestarted reports that a runner process was started again, after
+	// a crash or after being terminated by a timeout or a canceled context,
+	// or that an unresponsive runner was replaced, see WithHealthCheck.
+	RunnerRestarted()
+
+	// BytesHashed reports the bytes of the payload and tag files hashed by a
+	// validation. They are reported as they are hashed by BackendNative and
+	// by validations reporting their progress, see WithProgress. Otherwise,
+	// the runner reports their total once the validation succeeds, so that
+	// measuring does not change how bags are validated.
+	BytesHashed(n int64)
+
+	// Bootstrap reports how long the extraction of the embedded runtime took.
+	Bootstrap(d time.Duration)
+}
+
+// WithMetrics reports measurements of the Validator pool to m. By default,
+// nothing is measured.
+func WithMetrics(m Metrics) ValidatorOption {
+	return func(cfg *validatorConfig) {
+		cfg.metrics = m
+	}
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) PoolSize(int)                                {}
+func (nopMetrics) Runners(int)                                 {}
+func (nopMetrics) RunnersBusy(int)                             {}
+func (nopMetrics) AcquireWait(time.Duration)                   {}
+func (nopMetrics) Validation(ValidationOutcome, time.Duration) {}
+func (nopMetrics) RunnerRestarted()                            {}
+func (nopMetrics) BytesHashed(int64)                           {}
+func (nopMetrics) Bootstrap(time.Duration)                     {}
+
+// withBytesHashed reports the bytes hashed while validating fixity to fn.
+func withBytesHashed(fn func(n int64)) ValidateOption {
+	return func(req *validateRequest) {
+		req.bytesHashed = fn
+	}
+}
+
+// countProgressBytes wraps the ProgressFunc of req to report the bytes hashed
+// to its bytesHashed func, if set, as well as to the ProgressFunc set by
+// WithProgress, if any.
+func (req *validateRequest) countProgressBytes() {
+	fn := req.bytesHashed
+	if fn == nil {
+		return
+	}
+	progress := req.progress
+	var done int64
+	req.progress = func(p Progress) {
+		if p.Phase == PhaseFixity && p.BytesDone > done {
+			fn(p.BytesDone - done)
+		}
+		done = p.BytesDone
+		if progress != nil {
+			progress(p)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package bagit_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/artefactual-labs/bagit-gython"
+	"github.com/artefactual-labs/bagit-gython/bagittest"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/poll"
+)
+
+// recordedMetrics is a bagit.Metrics recording what it receives.
+type recordedMetrics struct {
+	mu          sync.Mutex
+	poolSize    int
+	runners     []int
+	busy        []int
+	waits       int
+	validations []bagit.ValidationOutcome
+	restarts    int
+	bytes       int64
+	bootstraps  int
+}
+
+func (m *recordedMetrics) PoolSize(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.poolSize = n
+}
+
+func (m *recordedMetrics) Runners(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runners = append(m.runners, n)
+}
+
+func (m *recordedMetrics) RunnersBusy(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.busy = append(m.busy, n)
+}
+
+func (m *recordedMetrics) AcquireWait(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.waits++
+}
+
+func (m *recordedMetrics) Validation(outcome bagit.ValidationOutcome, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.validations = append(m.validations, outcome)
+}
+
+func (m *recordedMetrics) RunnerRestarted() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.restarts++
+}
+
+func (m *recordedMetrics) BytesHashed(n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bytes += n
+}
+
+func (m *recordedMetrics) Bootstrap(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bootstraps++
+}
+
+func TestValidatorMetrics(t *testing.T) {
+	t.Parallel()
+
+	valid := bagittest.NewBuilder().
+		WithFile("hello.txt", "hello world").
+		WithFile("bye.txt", "bye").
+		BuildTemp(t)
+	invalid := bagittest.NewBuilder().
+		WithFile("hello.txt", "hello world").
+		WithCorruptions(bagittest.FlipByte).
+		BuildTemp(t)
+
+	for _, backend := range []bagit.Backend{bagit.BackendPython, bagit.BackendNative} {
+		t.Run(backend.String(), func(t *testing.T) {
+			t.Parallel()
+
+			m := &recordedMetrics{}
+			v, err := bagit.NewValidator(
+				bagit.WithTempCacheDir(),
+				bagit.WithPoolSize(2),
+				bagit.WithBackend(backend),
+				bagit.WithMetrics(m),
+			)
+			assert.NilError(t, err)
+
+			var progressed bool
+			assert.NilError(t, v.Validate(valid, bagit.WithProgress(func(bagit.Progress) {
+				progressed = true
+			})))
+			assert.ErrorIs(t, v.TryValidate(invalid), bagit.ErrInvalid)
+			assert.NilError(t, v.Close())
+			assert.ErrorIs(t, v.Validate(valid), bagit.ErrClosed)
+
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			assert.Assert(t, progressed)
+			assert.Equal(t, m.poolSize, 2)
+			assert.DeepEqual(t, m.busy, []int{1, 0, 1, 0})
+			assert.Equal(t, m.waits, 1)
+			assert.DeepEqual(t, m.validations, []bagit.ValidationOutcome{
+				bagit.OutcomeValid,
+				bagit.OutcomeInvalid,
+				bagit.OutcomeClosed,
+			})
+			assert.Assert(t, m.bytes > 14+11) // Tag files are hashed as well.
+			assert.Equal(t, m.restarts, 0)
+			if backend == bagit.BackendPython {
+				assert.Equal(t, m.bootstraps, 1)
+				assert.DeepEqual(t, m.runners, []int{2, 0})
+			} else {
+				assert.Equal(t, m.bootstraps, 0)
+				assert.DeepEqual(t, m.runners, []int(nil))
+			}
+		})
+	}
+}
+
+func TestValidatorMetricsBytesHashed(t *testing.T) {
+	t.Parallel()
+
+	path := bagittest.NewBuilder().
+		WithFile("hello.txt", "hello world").
+		WithFile("bye.txt", "bye").
+		BuildTemp(t)
+
+	m := &recordedMetrics{}
+	v, err := bagit.NewValidator(bagit.WithTempCacheDir(), bagit.WithMetrics(m))
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, v.Close())
+	})
+
+	// Without progress, the runner reports the same bytes once the bag is
+	// valid as the progress events.
+	assert.NilError(t, v.Validate(path, bagit.WithProgress(func(bagit.Progress) {})))
+	m.mu.Lock()
+	progressed := m.bytes
+	m.mu.Unlock()
+	assert.Assert(t, progressed > 14)
+
+	assert.NilError(t, v.Validate(path))
+	assert.NilError(t, v.Validate(path, bagit.WithFast()))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	assert.Equal(t, m.bytes, 2*progressed)
+}
+
+func TestValidatorMetricsPoolBounds(t *testing.T) {
+	t.Parallel()
+
+	m := &recordedMetrics{}
+	v, err := bagit.NewValidator(
+		bagit.WithTempCacheDir(),
+		bagit.WithPoolBounds(0, 2),
+		bagit.WithIdleTimeout(10*time.Millisecond),
+		bagit.WithMetrics(m),
+	)
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, v.Close())
+	})
+
+	assert.NilError(t, v.Validate("internal/testdata/valid-bag"))
+
+	// The runner created on demand is reported, then its removal.
+	poll.WaitOn(t, func(poll.LogT) poll.Result {
+		if v.Stats().Runners > 0 {
+			return poll.Continue("idle runner not stopped")
+		}
+		return poll.Success()
+	})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	assert.Equal(t, m.poolSize, 2)
+	assert.DeepEqual(t, m.runners, []int{0, 1, 0})
+}
@@ -0,0 +1,903 @@
+package bagit
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha3"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/unicode/norm"
+)
+
+// checksumAlgos lists the algorithms of the manifest files read by
+// bagit-python, i.e. hashlib.algorithms_guaranteed.
+var checksumAlgos = []string{
+	"blake2b", "blake2s", "md5", "sha1", "sha224", "sha256", "sha384",
+	"sha3_224", "sha3_256", "sha3_384", "sha3_512", "sha512",
+	"shake_128", "shake_256",
+}
+
+// nativeHashes lists the checksum algorithms supported by the native backend.
+var nativeHashes = map[string]func() hash.Hash{
+	"md5":      md5.New,
+	"sha1":     sha1.New,
+	"sha224":   sha256.New224,
+	"sha256":   sha256.New,
+	"sha384":   sha512.New384,
+	"sha512":   sha512.New,
+	"sha3_224": func() hash.Hash { return sha3.New224() },
+	"sha3_256": func() hash.Hash { return sha3.New256() },
+	"sha3_384": func() hash.Hash { return sha3.New384() },
+	"sha3_512": func() hash.Hash { return sha3.New512() },
+}
+
+// nativeError is a bag error found by the native backend, the equivalent of
+// the BagError and BagValidationError exceptions of bagit-python.
+type nativeError struct {
+	typ     string
+	msg     string
+	details []ValidationDetail
+}
+
+func (e *nativeError) Error() string {
+	if len(e.details) == 0 {
+		return e.msg
+	}
+
+	msgs := make([]string, 0, len(e.details))
+	for _, d := range e.details {
+		msgs = append(msgs, d.Message)
+	}
+
+	return fmt.Sprintf("%s: %s", e.msg, strings.Join(msgs, "; "))
+}
+
+func bagErrorf(format string, a ...any) error {
+	return &nativeError{typ: "BagError", msg: fmt.Sprintf(format, a...)}
+}
+
+func bagValidationErrorf(format string, a ...any) error {
+	return &nativeError{typ: "BagValidationError", msg: fmt.Sprintf(format, a...)}
+}
+
+// validateNative validates the bag at path, found in dir once extracted, in
+// Go, following the steps of Bag.validate in bagit-python, see BackendNative
+// and extractPath.
+func validateNative(ctx context.Context, path, dir string, timeout time.Duration, opts ...ValidateOption) error {
+	req := &validateRequest{Path: dir}
+	for _, opt := range opts {
+		opt(req)
+	}
+	req.countProgressBytes()
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, timeout, ErrTimeout)
+		defer cancel()
+	}
+
+	err := newNativeValidation(ctx, req).run(dir)
+	if ctx.Err() != nil {
+		return doneErr(ctx)
+	}
+
+	var nerr *nativeError
+	if errors.As(err, &nerr) {
+		resp := errorResponse{Err: nerr.Error(), Type: nerr.typ, Kind: "bag", Details: nerr.details}
+		return resp.validationError(path)
+	}
+	if err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
+
+// nativeEntry is a file listed in the manifests with its checksums, in the
+// order of the manifests.
+type nativeEntry struct {
+	path   string
+	hashes []nativeChecksum
+}
+
+type nativeChecksum struct {
+	alg string
+	sum string
+}
+
+// nativeValidation holds the state of a bag being validated, like the Bag
+// class of bagit-python.
+type nativeValidation struct {
+	ctx      context.Context
+	req      *validateRequest
+	progress *nativeProgress
+
+	path         string // Absolute path of the bag.
+	version      string
+	versionInfo  []int
+	encodingName string
+	encoding     encoding.Encoding // nil for UTF-8.
+	info         map[string][]string
+	algorithms   []string
+	entries      []*nativeEntry
+	entryIndex   map[string]*nativeEntry
+
+	// Unicode normalized names mapped to the names found in the manifests
+	// and on the filesystem.
+	normalizedManifestNames   map[string]string
+	normalizedFilesystemNames map[string]string
+
+	payload []string // Payload files, nil until listed.
+}
+
+func newNativeValidation(ctx context.Context, req *validateRequest) *nativeValidation {
+	return &nativeValidation{
+		ctx:                       ctx,
+		req:                       req,
+		progress:                  &nativeProgress{fn: req.progress},
+		info:                      map[string][]string{},
+		entryIndex:                map[string]*nativeEntry{},
+		normalizedManifestNames:   map[string]string{},
+		normalizedFilesystemNames: map[string]string{},
+	}
+}
+
+func (v *nativeValidation) run(path string) error {
+	var err error
+	v.path, err = filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	if err := v.open(); err != nil {
+		return err
+	}
+
+	v.progress.start(PhaseStructure, 0, 0)
+	if err := v.validateStructure(); err != nil {
+		return err
+	}
+	if err := v.validateBagItTxt(); err != nil {
+		return err
+	}
+	if err := v.validateFetch(); err != nil {
+		return err
+	}
+
+	_, hasOxum := v.info["Payload-Oxum"]
+	if v.req.Fast && !hasOxum {
+		return bagValidationErrorf("Fast validation requires bag-info.txt to include Payload-Oxum")
+	}
+
+	v.progress.start(PhaseOxum, 0, 0)
+	if err := v.validateOxum(); err != nil {
+		return err
+	}
+	if v.req.Fast {
+		return nil
+	}
+
+	v.progress.start(PhaseCompleteness, 0, 0)
+	if err := v.validateCompleteness(); err != nil {
+		return err
+	}
+	if v.req.CompletenessOnly {
+		return nil
+	}
+
+	return v.validateEntries()
+}
+
+// open reads bagit.txt, bag-info.txt and the manifests.
+func (v *nativeValidation) open() error {
+	bagitPath := filepath.Join(v.path, "bagit.txt")
+	if !isFile(bagitPath) {
+		return bagErrorf("Expected bagit.txt does not exist: %s", bagitPath)
+	}
+
+	text, err := v.readText(bagitPath, "utf-8-sig")
+	if err != nil {
+		return err
+	}
+	tags, err := parseTags(text, bagitPath)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, label := range []string{"BagIt-Version", "Tag-File-Character-Encoding"} {
+		if _, ok := tags[label]; !ok {
+			missing = append(missing, label)
+		}
+	}
+	if len(missing) > 0 {
+		return bagErrorf("Missing required tag in bagit.txt: %s", strings.Join(missing, ", "))
+	}
+
+	v.version = tags["BagIt-Version"][0]
+	for _, part := range strings.SplitN(v.version, ".", 2) {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return bagErrorf("Bag version numbers must be MAJOR.MINOR numbers, not %s", v.version)
+		}
+		v.versionInfo = append(v.versionInfo, n)
+	}
+
+	var tagFileName string
+	switch {
+	case v.versionAtLeast(0, 93) && !v.versionAtLeast(0, 96):
+		tagFileName = "package-info.txt"
+	case v.versionAtLeast(0, 96) && !v.versionAtLeast(2):
+		tagFileName = "bag-info.txt"
+	default:
+		return bagErrorf("Unsupported bag version: %s", v.version)
+	}
+
+	v.encodingName = tags["Tag-File-Character-Encoding"][0]
+	enc, ok := lookupEncoding(v.encodingName)
+	if !ok {
+		return bagValidationErrorf("Unsupported encoding: %s", v.encodingName)
+	}
+	v.encoding = enc
+
+	infoPath := filepath.Join(v.path, tagFileName)
+	if _, err := os.Stat(infoPath); err == nil {
+		text, err := v.readText(infoPath, v.encodingName)
+		if err != nil {
+			return err
+		}
+		if v.info, err = parseTags(text, infoPath); err != nil {
+			return err
+		}
+	}
+
+	return v.loadManifests()
+}
+
+// versionAtLeast compares the bag version with the given version, like
+// Python compares tuples.
+func (v *nativeValidation) versionAtLeast(version ...int) bool {
+	return slices.Compare(v.versionInfo, version) >= 0
+}
+
+func (v *nativeValidation) manifestFiles(prefix string) []string {
+	var files []string
+	for _, alg := range checksumAlgos {
+		name := filepath.Join(v.path, prefix+alg+".txt")
+		if isFile(name) {
+			files = append(files, name)
+		}
+	}
+
+	return files
+}
+
+var encodedNewline = regexp.MustCompile(`(?i)%0[ad]`)
+
+func (v *nativeValidation) loadManifests() error {
+	manifests := v.manifestFiles("manifest-")
+	if v.versionAtLeast(0, 97) {
+		// v0.97+ requires that optional tagfiles are verified.
+		manifests = append(manifests, v.manifestFiles("tagmanifest-")...)
+	}
+
+	for _, manifest := range manifests {
+		base := filepath.Base(manifest)
+		alg := strings.TrimSuffix(base[strings.Index(base, "-")+1:], ".txt")
+		if !slices.Contains(v.algorithms, alg) {
+			v.algorithms = append(v.algorithms, alg)
+		}
+
+		text, err := v.readText(manifest, v.encodingName)
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(v.encodingName, "UTF") {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+
+		for _, line := range splitLines(text) {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+
+			fields := splitFields(line, 2)
+			if len(fields) != 2 {
+				continue
+			}
+
+			sum := fields[0]
+			entryPath := filepath.Clean(strings.TrimLeft(fields[1], "*"))
+			entryPath = encodedNewline.ReplaceAllStringFunc(entryPath, func(s string) string {
+				if strings.EqualFold(s, "%0D") {
+					return "\r"
+				}
+				return "\n"
+			})
+
+			if v.pathIsDangerous(entryPath) {
+				return bagErrorf(`Path "%s" in manifest "%s" is unsafe`, entryPath, manifest)
+			}
+
+			entry, ok := v.entryIndex[entryPath]
+			if !ok {
+				entry = &nativeEntry{path: entryPath}
+				v.entryIndex[entryPath] = entry
+				v.entries = append(v.entries, entry)
+			}
+
+			i := slices.IndexFunc(entry.hashes, func(c nativeChecksum) bool { return c.alg == alg })
+			if i >= 0 {
+				if entry.hashes[i].sum != sum {
+					return bagErrorf("%s: %s manifest lists %s multiple times with conflicting values", v.path, alg, entryPath)
+				}
+				if v.versionAtLeast(1) {
+					return bagErrorf("%s: %s manifest lists %s multiple times with the same value", v.path, alg, entryPath)
+				}
+				continue
+			}
+			entry.hashes = append(entry.hashes, nativeChecksum{alg: alg, sum: sum})
+		}
+	}
+
+	for _, entry := range v.entries {
+		v.normalizedManifestNames[norm.NFC.String(entry.path)] = entry.path
+	}
+
+	return nil
+}
+
+// pathIsDangerous reports whether path may refer to a file outside the bag.
+func (v *nativeValidation) pathIsDangerous(path string) bool {
+	if filepath.IsAbs(path) {
+		return true
+	}
+	if name, ok := strings.CutPrefix(path, "~"); ok {
+		name, _, _ = strings.Cut(name, string(filepath.Separator))
+		if name == "" {
+			return true
+		}
+		if _, err := user.Lookup(name); err == nil {
+			return true
+		}
+	}
+
+	bagPath := realPath(v.path)
+	rel, err := filepath.Rel(bagPath, realPath(filepath.Join(v.path, path)))
+
+	return err != nil || (rel != "." && !filepath.IsLocal(rel))
+}
+
+func (v *nativeValidation) validateStructure() error {
+	dataPath := filepath.Join(v.path, "data")
+	if st, err := os.Stat(dataPath); err != nil || !st.IsDir() {
+		return bagValidationErrorf("Expected data directory %s does not exist", dataPath)
+	}
+
+	if len(v.manifestFiles("manifest-")) == 0 {
+		return bagValidationErrorf("No manifest files found")
+	}
+
+	return nil
+}
+
+func (v *nativeValidation) validateBagItTxt() error {
+	f, err := os.Open(filepath.Join(v.path, "bagit.txt"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	head := make([]byte, 4)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	if bytes.HasPrefix(head[:n], []byte("\xef\xbb\xbf")) {
+		return bagValidationErrorf("bagit.txt must not contain a byte-order mark")
+	}
+
+	return nil
+}
+
+func (v *nativeValidation) validateFetch() error {
+	fetchPath := filepath.Join(v.path, "fetch.txt")
+	if !isFile(fetchPath) {
+		return nil
+	}
+
+	text, err := v.readText(fetchPath, v.encodingName)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range splitLines(text) {
+		fields := splitFields(strings.TrimSpace(line), 3)
+		if len(fields) != 3 {
+			return bagErrorf(`Malformed line in "%s": %s`, fetchPath, strings.TrimSpace(line))
+		}
+		rawURL, filename := fields[0], fields[2]
+
+		if v.pathIsDangerous(filename) {
+			return bagErrorf(`Path "%s" in "%s" is unsafe`, filename, fetchPath)
+		}
+
+		u, err := url.Parse(rawURL)
+		if err != nil || !((u.Scheme != "" && u.Host != "") || u.Scheme == "file") {
+			return bagErrorf("Malformed URL in fetch.txt: %s", rawURL)
+		}
+	}
+
+	return nil
+}
+
+// payloadFiles lists the files below the data directory relative to the bag,
+// without following links to directories.
+func (v *nativeValidation) payloadFiles() ([]string, error) {
+	if v.payload != nil {
+		return v.payload, nil
+	}
+
+	files := []string{}
+	err := filepath.WalkDir(filepath.Join(v.path, "data"), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Like os.walk, skip directories that cannot be read.
+			return nil
+		}
+		if err := v.ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			if st, err := os.Stat(p); err == nil && st.IsDir() {
+				return nil
+			}
+		}
+
+		rel, err := filepath.Rel(v.path, p)
+		if err != nil {
+			return err
+		}
+		v.normalizedFilesystemNames[norm.NFC.String(rel)] = rel
+		files = append(files, rel)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	v.payload = files
+
+	return files, nil
+}
+
+func (v *nativeValidation) validateOxum() error {
+	values, ok := v.info["Payload-Oxum"]
+	if !ok {
+		return nil
+	}
+	oxum := values[0]
+
+	byteCount, fileCount, ok := strings.Cut(oxum, ".")
+	if !ok || !isDigits(byteCount) || !isDigits(fileCount) {
+		return bagErrorf("Malformed Payload-Oxum value: %s", oxum)
+	}
+	expectedBytes, err := strconv.ParseInt(byteCount, 10, 64)
+	if err != nil {
+		return bagErrorf("Malformed Payload-Oxum value: %s", oxum)
+	}
+	expectedFiles, err := strconv.ParseInt(fileCount, 10, 64)
+	if err != nil {
+		return bagErrorf("Malformed Payload-Oxum value: %s", oxum)
+	}
+
+	files, err := v.payloadFiles()
+	if err != nil {
+		return err
+	}
+	var totalBytes int64
+	for _, name := range files {
+		st, err := os.Stat(filepath.Join(v.path, name))
+		if err != nil {
+			return err
+		}
+		totalBytes += st.Size()
+	}
+	totalFiles := int64(len(files))
+
+	if expectedFiles != totalFiles || expectedBytes != totalBytes {
+		return bagValidationErrorf(
+			"Payload-Oxum validation failed. Expected %d files and %d bytes but found %d files and %d bytes",
+			expectedFiles, expectedBytes, totalFiles, totalBytes,
+		)
+	}
+
+	return nil
+}
+
+func (v *nativeValidation) validateCompleteness() error {
+	files, err := v.payloadFiles()
+	if err != nil {
+		return err
+	}
+
+	onFS := map[string]bool{}
+	for _, name := range files {
+		onFS[norm.NFC.String(name)] = true
+	}
+	inManifest := map[string]bool{}
+	dataPrefix := "data" + string(filepath.Separator)
+	for _, entry := range v.entries {
+		if strings.HasPrefix(entry.path, dataPrefix) {
+			inManifest[norm.NFC.String(entry.path)] = true
+		} else if v.versionAtLeast(0, 97) && !isFile(filepath.Join(v.path, entry.path)) {
+			// Missing optional tag files.
+			inManifest[entry.path] = true
+		}
+	}
+
+	var onlyInManifest, onlyOnFS []string
+	for name := range inManifest {
+		if !onFS[name] {
+			if orig, ok := v.normalizedManifestNames[name]; ok {
+				name = orig
+			}
+			onlyInManifest = append(onlyInManifest, name)
+		}
+	}
+	for name := range onFS {
+		if !inManifest[name] {
+			onlyOnFS = append(onlyOnFS, v.normalizedFilesystemNames[name])
+		}
+	}
+	slices.Sort(onlyInManifest)
+	slices.Sort(onlyOnFS)
+
+	var details []ValidationDetail
+	for _, name := range onlyInManifest {
+		details = append(details, ValidationDetail{
+			Type:    FileMissing,
+			Path:    name,
+			Message: (&FileMissingError{Path: name}).Error(),
+		})
+	}
+	for _, name := range onlyOnFS {
+		details = append(details, ValidationDetail{
+			Type:    UnexpectedFile,
+			Path:    name,
+			Message: (&UnexpectedFileError{Path: name}).Error(),
+		})
+	}
+	if len(details) > 0 {
+		return &nativeError{typ: "BagValidationError", msg: "Bag is incomplete", details: details}
+	}
+
+	return nil
+}
+
+// nativeHashResult holds the checksums computed for the entry at index i.
+type nativeHashResult struct {
+	i     int
+	path  string // Filesystem name, relative to the bag.
+	size  int64
+	found []string
+}
+
+func (v *nativeValidation) validateEntries() error {
+	for _, alg := range v.algorithms {
+		if _, ok := nativeHashes[alg]; !ok {
+			return fmt.Errorf("%w: checksum algorithm %q", errors.ErrUnsupported, alg)
+		}
+	}
+
+	paths := make([]string, len(v.entries))
+	var bytesTotal int64
+	for i, entry := range v.entries {
+		paths[i] = entry.path
+		if name, ok := v.normalizedFilesystemNames[entry.path]; ok {
+			paths[i] = name
+		}
+		if st, err := os.Stat(filepath.Join(v.path, paths[i])); err == nil {
+			bytesTotal += st.Size()
+		}
+	}
+	v.progress.start(PhaseFixity, int64(len(paths)), bytesTotal)
+
+	workers := v.req.Processes
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	jobs := make(chan int)
+	results := make(chan nativeHashResult)
+	var wg sync.WaitGroup
+	for range min(workers, max(len(paths), 1)) {
+		wg.Go(func() {
+			for i := range jobs {
+				results <- v.hashEntry(i, paths[i])
+			}
+		})
+	}
+	go func() {
+		defer close(jobs)
+		for i := range paths {
+			select {
+			case jobs <- i:
+			case <-v.ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	found := make([]*nativeHashResult, len(paths))
+	for r := range results {
+		found[r.i] = &r
+		v.progress.fileDone(r.path, r.size)
+	}
+	if err := v.ctx.Err(); err != nil {
+		return err
+	}
+
+	var details []ValidationDetail
+	for i, entry := range v.entries {
+		r := found[i]
+		for j, c := range entry.hashes {
+			expected := strings.ToLower(c.sum)
+			if expected == r.found[j] {
+				continue
+			}
+			merr := &ChecksumMismatchError{Path: r.path, Algorithm: c.alg, Expected: expected, Found: r.found[j]}
+			details = append(details, ValidationDetail{
+				Type:      ChecksumMismatch,
+				Path:      r.path,
+				Algorithm: c.alg,
+				Expected:  expected,
+				Found:     r.found[j],
+				Message:   merr.Error(),
+			})
+		}
+	}
+	if len(details) > 0 {
+		return &nativeError{typ: "BagValidationError", msg: "Bag validation failed", details: details}
+	}
+
+	return nil
+}
+
+// hashEntry computes the checksums of the entry at index i, stored at path.
+// Files that cannot be read get the read error as checksum, like
+// bagit-python.
+func (v *nativeValidation) hashEntry(i int, path string) nativeHashResult {
+	entry := v.entries[i]
+	r := nativeHashResult{i: i, path: path, found: make([]string, len(entry.hashes))}
+
+	hashers := make([]hash.Hash, len(entry.hashes))
+	writers := make([]io.Writer, len(entry.hashes))
+	for j, c := range entry.hashes {
+		hashers[j] = nativeHashes[c.alg]()
+		writers[j] = hashers[j]
+	}
+
+	full := filepath.Join(v.path, path)
+	n, err := copyFile(v.ctx, io.MultiWriter(writers...), full)
+	r.size = n
+	for j, h := range hashers {
+		if err != nil {
+			r.found[j] = fmt.Sprintf("Could not read %s: %v", full, err)
+			continue
+		}
+		r.found[j] = hex.EncodeToString(h.Sum(nil))
+	}
+
+	return r
+}
+
+func copyFile(ctx context.Context, w io.Writer, name string) (int64, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return io.Copy(w, ctxReader{ctx: ctx, r: f})
+}
+
+// readText reads a text file with the given Python codec name, translating
+// line endings like Python text files do.
+func (v *nativeValidation) readText(name, encodingName string) (string, error) {
+	blob, err := os.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+
+	if encodingName == "utf-8-sig" {
+		blob = bytes.TrimPrefix(blob, []byte("\xef\xbb\xbf"))
+	} else if v.encoding != nil {
+		if blob, err = v.encoding.NewDecoder().Bytes(blob); err != nil {
+			return "", fmt.Errorf("decode %s: %v", name, err)
+		}
+	}
+	if !utf8.Valid(blob) {
+		return "", fmt.Errorf("decode %s: invalid %s", name, encodingName)
+	}
+
+	text := strings.ReplaceAll(string(blob), "\r\n", "\n")
+
+	return strings.ReplaceAll(text, "\r", "\n"), nil
+}
+
+// lookupEncoding returns the text encoding named like a Python codec, nil for
+// UTF-8.
+func lookupEncoding(name string) (encoding.Encoding, bool) {
+	key := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "_", "-")
+	switch key {
+	case "utf-8", "utf8", "u8", "utf", "utf8-ucs2", "utf8-ucs4", "cp65001", "utf-8-sig":
+		return nil, true
+	case "latin-1":
+		key = "latin1"
+	}
+
+	enc, err := ianaindex.IANA.Encoding(key)
+	if err != nil || enc == nil {
+		return nil, false
+	}
+
+	return enc, true
+}
+
+// parseTags parses a tag file like _load_tag_file of bagit-python, including
+// folded lines. Repeated labels keep every value in file order.
+func parseTags(text, name string) (map[string][]string, error) {
+	tags := map[string][]string{}
+	var label, value string
+	inTag := false
+	flush := func() {
+		if inTag && label != "" {
+			tags[label] = append(tags[label], strings.TrimSpace(value))
+		}
+	}
+
+	for _, line := range splitLines(text) {
+		switch {
+		case strings.TrimSpace(line) == "":
+			continue
+		case isSpace(line) && inTag:
+			value += line
+		default:
+			flush()
+			before, after, ok := strings.Cut(strings.TrimSpace(line), ":")
+			if !ok {
+				return nil, bagValidationErrorf("%s contains invalid tag: %s", filepath.Base(name), strings.TrimSpace(line))
+			}
+			label, value, inTag = strings.TrimSpace(before), after, true
+		}
+	}
+	flush()
+
+	return tags, nil
+}
+
+// isSpace reports whether line starts with white space.
+func isSpace(line string) bool {
+	r, _ := utf8.DecodeRuneInString(line)
+
+	return unicode.IsSpace(r)
+}
+
+// splitLines splits text after each newline, like iterating a Python file.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	return strings.SplitAfter(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// splitFields splits s around runs of white space into at most n fields, like
+// Python's str.split(None, n-1).
+func splitFields(s string, n int) []string {
+	var fields []string
+	s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	for s != "" {
+		if len(fields) == n-1 {
+			return append(fields, s)
+		}
+		i := strings.IndexFunc(s, unicode.IsSpace)
+		if i < 0 {
+			return append(fields, s)
+		}
+		fields = append(fields, s[:i])
+		s = strings.TrimLeftFunc(s[i:], unicode.IsSpace)
+	}
+
+	return fields
+}
+
+func isDigits(s string) bool {
+	return s != "" && strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' }) < 0
+}
+
+func isFile(name string) bool {
+	st, err := os.Stat(name)
+
+	return err == nil && st.Mode().IsRegular()
+}
+
+// realPath resolves the symbolic links of the longest existing prefix of
+// name, like Python's os.path.realpath.
+func realPath(name string) string {
+	name = filepath.Clean(name)
+	rest := ""
+	for {
+		if resolved, err := filepath.EvalSymlinks(name); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		parent := filepath.Dir(name)
+		if parent == name {
+			return filepath.Join(name, rest)
+		}
+		rest = filepath.Join(filepath.Base(name), rest)
+		name = parent
+	}
+}
+
+// nativeProgress reports the progress of a native validation, at most ten
+// times per second while files are hashed like the runner.
+type nativeProgress struct {
+	fn   ProgressFunc
+	p    Progress
+	last time.Time
+}
+
+func (p *nativeProgress) start(phase Phase, files, bytes int64) {
+	p.p = Progress{Phase: phase, FilesTotal: files, BytesTotal: bytes}
+	p.report(true)
+}
+
+func (p *nativeProgress) fileDone(path string, size int64) {
+	p.p.FilesDone++
+	p.p.BytesDone += size
+	p.p.Path = filepath.ToSlash(path)
+	p.report(p.p.FilesDone == p.p.FilesTotal)
+}
+
+func (p *nativeProgress) report(force bool) {
+	if p.fn == nil {
+		return
+	}
+	if now := time.Now(); force || now.Sub(p.last) >= 100*time.Millisecond {
+		p.last = now
+		p.fn(p.p)
+	}
+}
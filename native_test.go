@@ -0,0 +1,237 @@
+package bagit_test
+
+import (
+	"context"
+	"errors"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/artefactual-labs/bagit-gython"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestNativeBackend(t *testing.T) {
+	t.Parallel()
+
+	native, err := bagit.NewValidator(bagit.WithBackend(bagit.BackendNative), bagit.WithTempCacheDir())
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, native.Close())
+	})
+	python := setUp(t)
+
+	// copyBag copies internal/testdata/valid-bag, then calls fn to break it.
+	copyBag := func(t *testing.T, fn func(path string)) string {
+		dir := fs.NewDir(t, "", fs.FromDir("internal/testdata/valid-bag"))
+		if fn != nil {
+			fn(dir.Path())
+		}
+		return dir.Path()
+	}
+	write := func(t *testing.T, name, content string) {
+		assert.NilError(t, os.WriteFile(name, []byte(content), 0o644))
+	}
+
+	for name, tc := range map[string]struct {
+		path func(t *testing.T) string
+		opts []bagit.ValidateOption
+	}{
+		"Valid bag": {
+			path: func(t *testing.T) string { return copyBag(t, nil) },
+		},
+		"Checksum mismatch": {
+			path: func(t *testing.T) string {
+				return copyBag(t, func(path string) {
+					write(t, filepath.Join(path, "bag-info.txt"), "Payload-Oxum: 0.1\nBagging-Date: 2024-04-20\n")
+				})
+			},
+		},
+		"Renamed payload file": {
+			path: func(t *testing.T) string {
+				return copyBag(t, func(path string) {
+					assert.NilError(t, os.Rename(filepath.Join(path, "data", "hola.txt"), filepath.Join(path, "data", "adios.txt")))
+				})
+			},
+		},
+		"Payload-Oxum mismatch": {
+			path: func(t *testing.T) string {
+				return copyBag(t, func(path string) {
+					write(t, filepath.Join(path, "data", "hola.txt"), "hola")
+				})
+			},
+		},
+		"Fast validation without Payload-Oxum": {
+			path: func(t *testing.T) string {
+				return copyBag(t, func(path string) {
+					write(t, filepath.Join(path, "bag-info.txt"), "Bagging-Date: 2024-04-19\n")
+				})
+			},
+			opts: []bagit.ValidateOption{bagit.WithFast()},
+		},
+		"Completeness only": {
+			path: func(t *testing.T) string {
+				return copyBag(t, func(path string) {
+					write(t, filepath.Join(path, "bag-info.txt"), "Payload-Oxum: 0.1\n")
+				})
+			},
+			opts: []bagit.ValidateOption{bagit.WithCompletenessOnly()},
+		},
+		"Missing bagit.txt": {
+			path: func(t *testing.T) string {
+				return copyBag(t, func(path string) {
+					assert.NilError(t, os.Remove(filepath.Join(path, "bagit.txt")))
+				})
+			},
+		},
+		"Missing data directory": {
+			path: func(t *testing.T) string {
+				return copyBag(t, func(path string) {
+					assert.NilError(t, os.RemoveAll(filepath.Join(path, "data")))
+				})
+			},
+		},
+		"Missing tag": {
+			path: func(t *testing.T) string {
+				return copyBag(t, func(path string) {
+					write(t, filepath.Join(path, "bagit.txt"), "BagIt-Version: 0.97\n")
+				})
+			},
+		},
+		"Invalid tag": {
+			path: func(t *testing.T) string {
+				return copyBag(t, func(path string) {
+					write(t, filepath.Join(path, "bag-info.txt"), "Payload-Oxum: 0.1\nnot a tag\n")
+				})
+			},
+		},
+		"Unsupported encoding": {
+			path: func(t *testing.T) string {
+				return copyBag(t, func(path string) {
+					write(t, filepath.Join(path, "bagit.txt"), "BagIt-Version: 0.97\nTag-File-Character-Encoding: klingon\n")
+				})
+			},
+		},
+		"Unsafe manifest path": {
+			path: func(t *testing.T) string {
+				return copyBag(t, func(path string) {
+					write(t, filepath.Join(path, "manifest-sha256.txt"), "abcd  ../secret.txt\n")
+				})
+			},
+		},
+		"Malformed fetch URL": {
+			path: func(t *testing.T) string {
+				return copyBag(t, func(path string) {
+					write(t, filepath.Join(path, "fetch.txt"), "nowhere 4 data/more.txt\n")
+				})
+			},
+		},
+		"Malformed fetch line": {
+			path: func(t *testing.T) string {
+				return copyBag(t, func(path string) {
+					write(t, filepath.Join(path, "fetch.txt"), "https://example.com/more.txt 4\n")
+				})
+			},
+		},
+		"Missing bag": {
+			path: func(t *testing.T) string { return filepath.Join(t.TempDir(), "missing") },
+		},
+		"Serialized bag": {
+			path: func(t *testing.T) string { return writeArchive(t, "tar.gz", validBagEntries(t, "valid-bag")) },
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := tc.path(t)
+
+			want := python.Validate(path, tc.opts...)
+			got := native.Validate(path, tc.opts...)
+			if want == nil {
+				assert.NilError(t, got)
+				return
+			}
+
+			assert.Equal(t, sortedMessage(got.Error()), sortedMessage(want.Error()))
+			assert.Equal(t, errors.Is(got, iofs.ErrNotExist), errors.Is(want, iofs.ErrNotExist))
+			assert.Equal(t, errors.Is(got, bagit.ErrInvalid), errors.Is(want, bagit.ErrInvalid))
+
+			var wantBag, gotBag *bagit.BagError
+			assert.Assert(t, errors.As(want, &wantBag))
+			assert.Assert(t, errors.As(got, &gotBag))
+			assert.Equal(t, gotBag.Type, wantBag.Type)
+			assert.Equal(t, gotBag.Path, wantBag.Path)
+			if errors.Is(want, iofs.ErrNotExist) {
+				return
+			}
+
+			var wantErr, gotErr *bagit.ValidationError
+			assert.Assert(t, errors.As(want, &wantErr))
+			assert.Assert(t, errors.As(got, &gotErr))
+			assert.DeepEqual(t, sortedReport(gotErr.Report), sortedReport(wantErr.Report))
+		})
+	}
+
+	t.Run("Reports progress", func(t *testing.T) {
+		var phases []bagit.Phase
+		var last bagit.Progress
+		err := native.Validate("internal/testdata/valid-bag", bagit.WithProgress(func(p bagit.Progress) {
+			if len(phases) == 0 || phases[len(phases)-1] != p.Phase {
+				phases = append(phases, p.Phase)
+			}
+			last = p
+		}))
+		assert.NilError(t, err)
+		assert.DeepEqual(t, phases, []bagit.Phase{bagit.PhaseStructure, bagit.PhaseOxum, bagit.PhaseCompleteness, bagit.PhaseFixity})
+		assert.Equal(t, last.FilesDone, int64(5))
+		assert.Equal(t, last.FilesDone, last.FilesTotal)
+	})
+
+	t.Run("Stops when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := native.ValidateContext(ctx, "internal/testdata/valid-bag")
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("Reports unsupported algorithms", func(t *testing.T) {
+		path := copyBag(t, func(path string) {
+			write(t, filepath.Join(path, "manifest-blake2b.txt"), strings.Repeat("0", 128)+"  data/hola.txt\n")
+		})
+
+		err := native.Validate(path)
+		assert.ErrorIs(t, err, errors.ErrUnsupported)
+	})
+
+	t.Run("Rejects unknown backends", func(t *testing.T) {
+		_, err := bagit.NewValidator(bagit.WithBackend(bagit.Backend(42)))
+		assert.Error(t, err, "unknown backend: Backend(42)")
+	})
+}
+
+// sortedMessage sorts the details listed in a validation error message.
+// bagit-python reads manifests in set order, so the order of the checksums of
+// each file varies between runs.
+func sortedMessage(msg string) string {
+	prefix, details, ok := strings.Cut(msg, "failed: ")
+	if !ok {
+		return msg
+	}
+	parts := strings.Split(details, "; ")
+	slices.Sort(parts)
+
+	return prefix + "failed: " + strings.Join(parts, "; ")
+}
+
+func sortedReport(r bagit.ValidationReport) bagit.ValidationReport {
+	r.Message = sortedMessage(r.Message)
+	r.Details = slices.Clone(r.Details)
+	slices.SortFunc(r.Details, func(a, b bagit.ValidationDetail) int {
+		return strings.Compare(a.Message, b.Message)
+	})
+
+	return r
+}
@@ -0,0 +1,65 @@
+package bagit
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// runnerOption returns a ValidatorOption configuring the runners of a
+// Validator or BagIt with fn.
+func runnerOption(fn func(*runnerConfig)) ValidatorOption {
+	return func(cfg *validatorConfig) {
+		fn(&cfg.runner)
+	}
+}
+
+// WithLogger sets the logger receiving the output of the runner processes.
+//
+// Python logging records, e.g. those emitted by bagit-python, are logged with
+// their level, logger name and message, plus the path of the bag being
+// processed. Any other line written to standard error, such as a traceback,
+// is logged as a warning. Runners owned by a Validator add their index in the
+// pool. By default, the output is discarded.
+func WithLogger(logger *slog.Logger) ValidatorOption {
+	return runnerOption(func(cfg *runnerConfig) {
+		cfg.logger = logger
+	})
+}
+
+// WithRequestTimeout bounds how long a single command, e.g. a validation, may
+// run. When the timeout expires, the runner process and its workers are
+// terminated and the command returns ErrTimeout. The next command starts a new
+// process, so a command stuck on an unresponsive file system does not hold a
+// runner forever.
+//
+// The timeout starts once the runner process is spawned. It does not include
+// the time spent waiting for an available runner of a Validator or for the
+// backoff of a restart, use a context deadline for that. The start of Python
+// and the import of bagit-python by a new process do count, see WithWarmup.
+// The default of 0 means no limit.
+func WithRequestTimeout(d time.Duration) ValidatorOption {
+	return runnerOption(func(cfg *runnerConfig) {
+		cfg.requestTimeout = d
+	})
+}
+
+func (cfg runnerConfig) validate() error {
+	if cfg.maxRestarts < 0 {
+		return fmt.Errorf("max restarts must not be negative")
+	}
+	if cfg.requestTimeout < 0 {
+		return fmt.Errorf("request timeout must not be negative")
+	}
+
+	return nil
+}
+
+// withIndex returns the configuration of the runner at index i of a pool.
+func (cfg runnerConfig) withIndex(i int) runnerConfig {
+	if cfg.logger != nil {
+		cfg.logger = cfg.logger.With("runner", i)
+	}
+
+	return cfg
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package bagit
+
+import "os/exec"
+
+// setProcessGroup is a no-op on platforms without process groups.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills the process started by cmd. Without process groups,
+// multiprocessing workers are not killed with it.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
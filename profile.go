@@ -0,0 +1,397 @@
+package bagit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Profile is a BagIt Profile, as described by the bagit-profiles
+// specification (https://bagit-profiles.github.io/bagit-profiles-specification/).
+// Use ParseProfile or LoadProfile to read one from JSON, and ValidateProfile
+// to check a bag against it.
+type Profile struct {
+	Info ProfileInfo `json:"BagIt-Profile-Info"`
+
+	// BagInfo lists the constraints on bag-info.txt tags by label.
+	BagInfo map[string]ProfileTag `json:"Bag-Info,omitempty"`
+
+	ManifestsRequired    []Algorithm `json:"Manifests-Required,omitempty"`
+	ManifestsAllowed     []Algorithm `json:"Manifests-Allowed,omitempty"`
+	TagManifestsRequired []Algorithm `json:"Tag-Manifests-Required,omitempty"`
+	TagManifestsAllowed  []Algorithm `json:"Tag-Manifests-Allowed,omitempty"`
+
+	// TagFilesRequired and TagFilesAllowed list tag file paths relative to
+	// the bag. TagFilesAllowed entries may use path.Match patterns.
+	TagFilesRequired []string `json:"Tag-Files-Required,omitempty"`
+	TagFilesAllowed  []string `json:"Tag-Files-Allowed,omitempty"`
+
+	// AllowFetch reports whether fetch.txt is allowed, true if unset.
+	AllowFetch    *bool `json:"Allow-Fetch.txt,omitempty"`
+	FetchRequired bool  `json:"Fetch.txt-Required,omitempty"`
+
+	// DataEmpty requires the payload to hold at most one empty file.
+	DataEmpty bool `json:"Data-Empty,omitempty"`
+
+	// Serialization is "required", "optional" or "forbidden".
+	Serialization string `json:"Serialization,omitempty"`
+
+	// AcceptSerialization lists the accepted media types of serialized bags,
+	// e.g. "application/zip".
+	AcceptSerialization []string `json:"Accept-Serialization,omitempty"`
+
+	AcceptBagItVersion []string `json:"Accept-BagIt-Version,omitempty"`
+}
+
+// ProfileInfo describes a BagIt Profile.
+type ProfileInfo struct {
+	Identifier          string `json:"BagIt-Profile-Identifier"`
+	ProfileVersion      string `json:"BagIt-Profile-Version,omitempty"`
+	SourceOrganization  string `json:"Source-Organization,omitempty"`
+	ExternalDescription string `json:"External-Description,omitempty"`
+	ContactName         string `json:"Contact-Name,omitempty"`
+	ContactEmail        string `json:"Contact-Email,omitempty"`
+	Version             string `json:"Version,omitempty"`
+}
+
+// ProfileTag constrains a bag-info.txt tag.
+type ProfileTag struct {
+	Required bool `json:"required,omitempty"`
+
+	// Values lists the allowed values, any value is allowed if empty.
+	Values []string `json:"values,omitempty"`
+
+	// Repeatable reports whether the tag may have multiple values, true if
+	// unset.
+	Repeatable *bool `json:"repeatable,omitempty"`
+
+	Description string `json:"description,omitempty"`
+}
+
+// ParseProfile parses a BagIt Profile encoded as JSON.
+func ParseProfile(data []byte) (*Profile, error) {
+	p := &Profile{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("parse profile: %v", err)
+	}
+
+	switch p.Serialization {
+	case "", "required", "optional", "forbidden":
+	default:
+		return nil, fmt.Errorf("parse profile: invalid Serialization value: %q", p.Serialization)
+	}
+
+	return p, nil
+}
+
+// LoadProfile reads a BagIt Profile from a JSON file.
+func LoadProfile(name string) (*Profile, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("load profile: %v", err)
+	}
+
+	return ParseProfile(data)
+}
+
+// ProfileViolation describes a way in which a bag does not conform to a
+// profile.
+type ProfileViolation struct {
+	// Field is the profile field that is violated, e.g. "Bag-Info" or
+	// "Manifests-Required".
+	Field string `json:"field"`
+
+	// Value is the offending tag label, algorithm, file or media type, if
+	// any.
+	Value string `json:"value,omitempty"`
+
+	// Message describes the violation.
+	Message string `json:"message"`
+}
+
+// ProfileError is returned when a bag does not conform to a profile. It wraps
+// ErrInvalid and lists every violation found.
+type ProfileError struct {
+	Profile    string // Identifier of the profile.
+	Violations []ProfileViolation
+}
+
+func (e *ProfileError) Error() string {
+	msg := fmt.Sprintf("%v: bag does not conform to profile %q", ErrInvalid, e.Profile)
+	if len(e.Violations) > 0 {
+		msg = fmt.Sprintf("%s: %s", msg, e.Violations[0].Message)
+	}
+	if n := len(e.Violations) - 1; n > 0 {
+		msg = fmt.Sprintf("%s (and %d more)", msg, n)
+	}
+
+	return msg
+}
+
+func (e *ProfileError) Unwrap() error {
+	return ErrInvalid
+}
+
+// mediaTypes lists the media types matching a serialization format.
+var mediaTypes = map[Format][]string{
+	FormatZip:     {"application/zip", "application/x-zip-compressed"},
+	FormatTar:     {"application/tar", "application/x-tar"},
+	FormatTarGzip: {"application/gzip", "application/x-gzip", "application/tar+gzip"},
+	FormatTarZstd: {"application/zstd"},
+}
+
+// profileCheck checks a bag against a profile. dir is the bag directory and
+// format the serialization of the bag as given, if any.
+type profileCheck struct {
+	profile    *Profile
+	bag        *Bag
+	dir        string
+	format     Format
+	violations []ProfileViolation
+}
+
+func (c *profileCheck) add(field, value, format string, a ...any) {
+	c.violations = append(c.violations, ProfileViolation{
+		Field:   field,
+		Value:   value,
+		Message: fmt.Sprintf(format, a...),
+	})
+}
+
+func (c *profileCheck) run() error {
+	c.checkBagInfo()
+	c.checkVersion()
+	c.checkSerialization()
+
+	manifests, err := c.manifestAlgorithms("manifest-")
+	if err != nil {
+		return err
+	}
+	c.checkManifests("Manifests-Required", "Manifests-Allowed", "manifest", manifests,
+		c.profile.ManifestsRequired, c.profile.ManifestsAllowed)
+
+	tagManifests, err := c.manifestAlgorithms("tagmanifest-")
+	if err != nil {
+		return err
+	}
+	c.checkManifests("Tag-Manifests-Required", "Tag-Manifests-Allowed", "tag manifest", tagManifests,
+		c.profile.TagManifestsRequired, c.profile.TagManifestsAllowed)
+
+	if err := c.checkFetch(); err != nil {
+		return err
+	}
+	if err := c.checkTagFiles(); err != nil {
+		return err
+	}
+
+	return c.checkDataEmpty()
+}
+
+func (c *profileCheck) checkBagInfo() {
+	labels := make([]string, 0, len(c.profile.BagInfo))
+	for label := range c.profile.BagInfo {
+		labels = append(labels, label)
+	}
+	slices.Sort(labels)
+
+	for _, label := range labels {
+		tag := c.profile.BagInfo[label]
+		values := c.bag.BagInfo.Values(label)
+		if len(values) == 0 {
+			if tag.Required {
+				c.add("Bag-Info", label, "Required tag %q is missing", label)
+			}
+			continue
+		}
+		if tag.Repeatable != nil && !*tag.Repeatable && len(values) > 1 {
+			c.add("Bag-Info", label, "Tag %q is not repeatable, found %d values", label, len(values))
+		}
+		if len(tag.Values) == 0 {
+			continue
+		}
+		for _, value := range values {
+			if !slices.Contains(tag.Values, value) {
+				c.add("Bag-Info", label, "Tag %q has value %q, expected one of: %s", label, value, strings.Join(tag.Values, ", "))
+			}
+		}
+	}
+}
+
+func (c *profileCheck) checkVersion() {
+	accepted := c.profile.AcceptBagItVersion
+	if len(accepted) > 0 && !slices.Contains(accepted, c.bag.Version) {
+		c.add("Accept-BagIt-Version", c.bag.Version, "BagIt version %q is not accepted, expected one of: %s", c.bag.Version, strings.Join(accepted, ", "))
+	}
+}
+
+func (c *profileCheck) checkSerialization() {
+	switch {
+	case c.format == "" && c.profile.Serialization == "required":
+		c.add("Serialization", "", "Bag must be serialized")
+	case c.format != "" && c.profile.Serialization == "forbidden":
+		c.add("Serialization", string(c.format), "Serialized bags are not allowed")
+	case c.format != "" && len(c.profile.AcceptSerialization) > 0:
+		for _, mt := range mediaTypes[c.format] {
+			if slices.Contains(c.profile.AcceptSerialization, mt) {
+				return
+			}
+		}
+		c.add("Accept-Serialization", mediaTypes[c.format][0], "Serialization %q is not accepted, expected one of: %s", mediaTypes[c.format][0], strings.Join(c.profile.AcceptSerialization, ", "))
+	}
+}
+
+// manifestAlgorithms returns the algorithms of the manifest files starting
+// with prefix, e.g. "manifest-".
+func (c *profileCheck) manifestAlgorithms(prefix string) ([]Algorithm, error) {
+	names, err := filepath.Glob(filepath.Join(c.dir, prefix+"*.txt"))
+	if err != nil {
+		return nil, err
+	}
+
+	algs := make([]Algorithm, 0, len(names))
+	for _, name := range names {
+		alg := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(name), prefix), ".txt")
+		algs = append(algs, Algorithm(alg))
+	}
+
+	return algs, nil
+}
+
+func (c *profileCheck) checkManifests(requiredField, allowedField, kind string, found, required, allowed []Algorithm) {
+	for _, alg := range required {
+		if !slices.Contains(found, alg) {
+			c.add(requiredField, string(alg), "Required %s %q is missing", kind, alg)
+		}
+	}
+	if len(allowed) == 0 {
+		return
+	}
+	for _, alg := range found {
+		if !slices.Contains(allowed, alg) {
+			c.add(allowedField, string(alg), "%s %q is not allowed", capitalize(kind), alg)
+		}
+	}
+}
+
+func (c *profileCheck) checkFetch() error {
+	_, err := os.Stat(filepath.Join(c.dir, "fetch.txt"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	exists := err == nil
+
+	if exists && c.profile.AllowFetch != nil && !*c.profile.AllowFetch {
+		c.add("Allow-Fetch.txt", "fetch.txt", "fetch.txt is not allowed")
+	}
+	if !exists && c.profile.FetchRequired {
+		c.add("Fetch.txt-Required", "fetch.txt", "fetch.txt is required")
+	}
+
+	return nil
+}
+
+// reservedTagFiles are the tag files defined by the BagIt specification, which
+// are always allowed.
+func reservedTagFile(name string) bool {
+	switch name {
+	case "bagit.txt", "bag-info.txt", "package-info.txt", "fetch.txt":
+		return true
+	}
+
+	return strings.HasPrefix(name, "manifest-") || strings.HasPrefix(name, "tagmanifest-")
+}
+
+func (c *profileCheck) checkTagFiles() error {
+	var tagFiles []string
+	err := filepath.WalkDir(c.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(c.dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel == "data" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		tagFiles = append(tagFiles, rel)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range c.profile.TagFilesRequired {
+		if !slices.Contains(tagFiles, name) {
+			c.add("Tag-Files-Required", name, "Required tag file %q is missing", name)
+		}
+	}
+
+	if len(c.profile.TagFilesAllowed) == 0 {
+		return nil
+	}
+	for _, name := range tagFiles {
+		if reservedTagFile(name) || slices.Contains(c.profile.TagFilesRequired, name) {
+			continue
+		}
+		allowed := false
+		for _, pattern := range c.profile.TagFilesAllowed {
+			if ok, _ := path.Match(pattern, name); ok {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			c.add("Tag-Files-Allowed", name, "Tag file %q is not allowed", name)
+		}
+	}
+
+	return nil
+}
+
+func (c *profileCheck) checkDataEmpty() error {
+	if !c.profile.DataEmpty {
+		return nil
+	}
+
+	var files []string
+	var size int64
+	err := filepath.WalkDir(filepath.Join(c.dir, "data"), func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, p)
+		size += info.Size()
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if len(files) > 1 || size > 0 {
+		c.add("Data-Empty", "data", "Payload must be empty, found %d files and %d bytes", len(files), size)
+	}
+
+	return nil
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+
+	return strings.ToUpper(s[:1]) + s[1:]
+}
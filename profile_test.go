@@ -0,0 +1,162 @@
+package bagit_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/artefactual-labs/bagit-gython"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+const testProfile = `{
+	"BagIt-Profile-Info": {
+		"BagIt-Profile-Identifier": "https://example.com/profile.json",
+		"BagIt-Profile-Version": "1.3.0",
+		"Source-Organization": "Example",
+		"Version": "1"
+	},
+	"Bag-Info": {
+		"Bagging-Date": {"required": true, "repeatable": false},
+		"Bag-Software-Agent": {"required": true}
+	},
+	"Manifests-Required": ["sha256"],
+	"Manifests-Allowed": ["sha256", "sha512"],
+	"Tag-Manifests-Required": ["sha512"],
+	"Allow-Fetch.txt": false,
+	"Data-Empty": true,
+	"Serialization": "optional",
+	"Accept-Serialization": ["application/zip"],
+	"Accept-BagIt-Version": ["0.97", "1.0"]
+}`
+
+func TestParseProfile(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Parses profiles", func(t *testing.T) {
+		p, err := bagit.ParseProfile([]byte(testProfile))
+		assert.NilError(t, err)
+		assert.Equal(t, p.Info.Identifier, "https://example.com/profile.json")
+		assert.DeepEqual(t, p.ManifestsRequired, []bagit.Algorithm{bagit.SHA256})
+		assert.Equal(t, *p.AllowFetch, false)
+		assert.Equal(t, *p.BagInfo["Bagging-Date"].Repeatable, false)
+		assert.Assert(t, p.BagInfo["Bag-Software-Agent"].Repeatable == nil)
+		assert.DeepEqual(t, p.AcceptBagItVersion, []string{"0.97", "1.0"})
+	})
+
+	t.Run("Loads profiles from files", func(t *testing.T) {
+		dir := fs.NewDir(t, "", fs.WithFile("profile.json", testProfile))
+
+		p, err := bagit.LoadProfile(dir.Join("profile.json"))
+		assert.NilError(t, err)
+		assert.Equal(t, p.Info.Version, "1")
+
+		_, err = bagit.LoadProfile(dir.Join("missing.json"))
+		assert.ErrorContains(t, err, "load profile: ")
+	})
+
+	t.Run("Rejects invalid profiles", func(t *testing.T) {
+		_, err := bagit.ParseProfile([]byte(`{"Serialization": "sometimes"}`))
+		assert.Error(t, err, `parse profile: invalid Serialization value: "sometimes"`)
+
+		_, err = bagit.ParseProfile([]byte(`[]`))
+		assert.ErrorContains(t, err, "parse profile: ")
+	})
+}
+
+func TestValidateProfile(t *testing.T) {
+	t.Parallel()
+
+	b := setUp(t)
+
+	profile, err := bagit.ParseProfile([]byte(testProfile))
+	assert.NilError(t, err)
+
+	t.Run("Accepts conforming bags", func(t *testing.T) {
+		assert.NilError(t, b.ValidateProfile("internal/testdata/valid-bag", profile))
+	})
+
+	t.Run("Reports every violation", func(t *testing.T) {
+		p, err := bagit.ParseProfile([]byte(`{
+			"BagIt-Profile-Info": {"BagIt-Profile-Identifier": "strict"},
+			"Bag-Info": {
+				"Source-Organization": {"required": true},
+				"Bagging-Date": {"values": ["2000-01-01"]}
+			},
+			"Manifests-Required": ["md5"],
+			"Manifests-Allowed": ["md5", "sha256"],
+			"Tag-Files-Required": ["dpn/info.txt"],
+			"Tag-Files-Allowed": ["dpn/*"],
+			"Fetch.txt-Required": true,
+			"Serialization": "required",
+			"Accept-BagIt-Version": ["1.0"]
+		}`))
+		assert.NilError(t, err)
+
+		dir := fs.NewDir(t, "", fs.FromDir("internal/testdata/valid-bag"), fs.WithFile("extra.txt", "hi"))
+
+		err = b.ValidateProfile(dir.Path(), p)
+		assert.ErrorIs(t, err, bagit.ErrInvalid)
+		assert.Error(t, err, `invalid: bag does not conform to profile "strict": Tag "Bagging-Date" has value "2024-04-19", expected one of: 2000-01-01 (and 8 more)`)
+
+		var perr *bagit.ProfileError
+		assert.Assert(t, errors.As(err, &perr))
+		assert.Equal(t, perr.Profile, "strict")
+		assert.DeepEqual(t, perr.Violations, []bagit.ProfileViolation{
+			{Field: "Bag-Info", Value: "Bagging-Date", Message: `Tag "Bagging-Date" has value "2024-04-19", expected one of: 2000-01-01`},
+			{Field: "Bag-Info", Value: "Source-Organization", Message: `Required tag "Source-Organization" is missing`},
+			{Field: "Accept-BagIt-Version", Value: "0.97", Message: `BagIt version "0.97" is not accepted, expected one of: 1.0`},
+			{Field: "Serialization", Message: "Bag must be serialized"},
+			{Field: "Manifests-Required", Value: "md5", Message: `Required manifest "md5" is missing`},
+			{Field: "Manifests-Allowed", Value: "sha512", Message: `Manifest "sha512" is not allowed`},
+			{Field: "Fetch.txt-Required", Value: "fetch.txt", Message: "fetch.txt is required"},
+			{Field: "Tag-Files-Required", Value: "dpn/info.txt", Message: `Required tag file "dpn/info.txt" is missing`},
+			{Field: "Tag-Files-Allowed", Value: "extra.txt", Message: `Tag file "extra.txt" is not allowed`},
+		})
+	})
+
+	t.Run("Requires reserved tag files", func(t *testing.T) {
+		p, err := bagit.ParseProfile([]byte(`{
+			"BagIt-Profile-Info": {"BagIt-Profile-Identifier": "reserved"},
+			"Tag-Files-Required": ["bag-info.txt", "fetch.txt"],
+			"Tag-Files-Allowed": ["extra.txt"]
+		}`))
+		assert.NilError(t, err)
+
+		var perr *bagit.ProfileError
+		assert.Assert(t, errors.As(b.ValidateProfile("internal/testdata/valid-bag", p), &perr))
+		assert.DeepEqual(t, perr.Violations, []bagit.ProfileViolation{
+			{Field: "Tag-Files-Required", Value: "fetch.txt", Message: `Required tag file "fetch.txt" is missing`},
+		})
+	})
+
+	t.Run("Checks serialized bags", func(t *testing.T) {
+		path := writeArchive(t, "zip", validBagEntries(t, "valid-bag"))
+		assert.NilError(t, b.ValidateProfile(path, profile))
+
+		path = writeArchive(t, "tar.gz", validBagEntries(t, "valid-bag"))
+		var perr *bagit.ProfileError
+		assert.Assert(t, errors.As(b.ValidateProfile(path, profile), &perr))
+		assert.DeepEqual(t, perr.Violations, []bagit.ProfileViolation{
+			{Field: "Accept-Serialization", Value: "application/gzip", Message: `Serialization "application/gzip" is not accepted, expected one of: application/zip`},
+		})
+	})
+
+	t.Run("Reports non-empty payloads", func(t *testing.T) {
+		dir := fs.NewDir(t, "", fs.FromDir("internal/testdata/valid-bag"), fs.WithDir("data", fs.WithFile("more.txt", "abc")))
+
+		var perr *bagit.ProfileError
+		assert.Assert(t, errors.As(b.ValidateProfile(dir.Path(), profile), &perr))
+		assert.DeepEqual(t, perr.Violations, []bagit.ProfileViolation{
+			{Field: "Data-Empty", Value: "data", Message: "Payload must be empty, found 2 files and 3 bytes"},
+		})
+	})
+
+	t.Run("Reports bags that cannot be read", func(t *testing.T) {
+		dir := fs.NewDir(t, "")
+
+		err := b.ValidateProfile(dir.Path(), profile)
+		var berr *bagit.BagError
+		assert.Assert(t, errors.As(err, &berr))
+	})
+}
@@ -0,0 +1,77 @@
+package bagit
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Phase is a step of a long-running command reported by Progress.
+type Phase string
+
+const (
+	// PhaseStructure checks the bag structure, bagit.txt and fetch.txt.
+	PhaseStructure Phase = "structure"
+
+	// PhaseOxum compares the payload with the Payload-Oxum in bag-info.txt.
+	PhaseOxum Phase = "oxum"
+
+	// PhaseCompleteness compares the manifests with the files in the bag.
+	PhaseCompleteness Phase = "completeness"
+
+	// PhaseFixity computes the checksums of the files listed in the manifests.
+	PhaseFixity Phase = "fixity"
+
+	// PhaseManifests computes the checksums of the payload when making a bag.
+	PhaseManifests Phase = "manifests"
+)
+
+// Progress describes how far a command has gotten. File and byte counts are
+// only reported for phases that hash files, i.e. PhaseFixity and
+// PhaseManifests; they are zero at the start of a phase.
+type Progress struct {
+	Phase      Phase  `json:"phase"`
+	FilesDone  int64  `json:"files_done"`
+	FilesTotal int64  `json:"files_total"`
+	BytesDone  int64  `json:"bytes_done"`
+	BytesTotal int64  `json:"bytes_total"`
+	Path       string `json:"path"` // Last file hashed, relative to the bag.
+}
+
+// ProgressFunc receives the progress of a command. It is called from the
+// goroutine running the command, which does not read the runner output until
+// ProgressFunc returns, so it should return quickly.
+type ProgressFunc func(Progress)
+
+// WithProgress calls fn when validation enters a new phase and, at most ten
+// times per second, while checksums are computed.
+func WithProgress(fn ProgressFunc) ValidateOption {
+	return func(req *validateRequest) {
+		req.Progress = fn != nil
+		req.progress = fn
+	}
+}
+
+// progressPrefix starts the interim lines written by the runner before the
+// response of a command.
+var progressPrefix = []byte(`{"progress":`)
+
+type progressEvent struct {
+	Progress Progress `json:"progress"`
+}
+
+// readProgress reports whether line is a progress event, passing it to fn.
+func readProgress(line []byte, fn ProgressFunc) (bool, error) {
+	if !bytes.HasPrefix(line, progressPrefix) {
+		return false, nil
+	}
+
+	var ev progressEvent
+	if err := json.Unmarshal(line, &ev); err != nil {
+		return true, err
+	}
+	if fn != nil {
+		fn(ev.Progress)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,72 @@
+package bagit
+
+import "slices"
+
+// WithMaxRequestsPerRunner retires a runner of a Validator once its Python
+// process has answered n commands, to bound the memory it accumulates. The
+// runner is replaced by a new one between commands, which starts a new process
+// on its next command. Health checks do not count. By default, runners are not
+// retired.
+func WithMaxRequestsPerRunner(n int) ValidatorOption {
+	return func(cfg *validatorConfig) {
+		cfg.maxRequests = n
+	}
+}
+
+// WithMaxRunnerRSS retires a runner of a Validator once the resident set size
+// of its Python process reaches bytes after a command, see
+// WithMaxRequestsPerRunner. The size is read from /proc/<pid>/status, so the
+// option has no effect on platforms other than Linux. By default, runners are
+// not retired.
+func WithMaxRunnerRSS(bytes int64) ValidatorOption {
+	return func(cfg *validatorConfig) {
+		cfg.maxRSS = bytes
+	}
+}
+
+// retire replaces b by a new runner if it crossed a limit set by
+// WithMaxRequestsPerRunner or WithMaxRunnerRSS, returning the runner to put
+// back into the pool. The process of b is stopped in the background.
+func (v *Validator) retire(b *BagIt) *BagIt {
+	r := b.runner
+	if r == nil || !r.running.Load() || (v.maxRequests == 0 && v.maxRSS == 0) {
+		return b
+	}
+
+	attrs := []any{"requests", r.requests}
+	retire := v.maxRequests > 0 && r.requests >= v.maxRequests
+	if v.maxRSS > 0 {
+		rss, err := r.rss()
+		if err != nil {
+			r.logger.Debug("read runner memory", "err", err)
+		}
+		attrs = append(attrs, "rss", rss)
+		retire = retire || rss >= v.maxRSS
+	}
+	if !retire {
+		return b
+	}
+
+	r.logger.Info("runner retired", attrs...)
+	v.background.Go(func() {
+		if err := b.Cleanup(); err != nil {
+			r.logger.Warn("stop retired runner", "err", err)
+		}
+	})
+
+	return v.replace(b)
+}
+
+// replace b in the pool by a new runner, which is returned.
+func (v *Validator) replace(b *BagIt) *BagIt {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	nb := newBagIt(v.runtime, false, v.runnerCfg.withIndex(v.runners))
+	v.runners++
+	if i := slices.Index(v.pool, b); i >= 0 {
+		v.pool[i] = nb
+	}
+
+	return nb
+}
@@ -0,0 +1,66 @@
+package bagit
+
+import (
+	"context"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+// poolRunner returns the only runner of v.
+func poolRunner(t *testing.T, v *Validator) *BagIt {
+	t.Helper()
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	assert.Equal(t, len(v.pool), 1)
+
+	return v.pool[0]
+}
+
+func TestValidatorRetiresRunnersAfterMaxRequests(t *testing.T) {
+	t.Parallel()
+
+	v, err := NewValidator(WithPoolSize(1), WithMaxRequestsPerRunner(2), WithTempCacheDir())
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, v.Close())
+	})
+
+	first := poolRunner(t, v)
+	assert.NilError(t, v.Validate("internal/testdata/valid-bag"))
+	assert.Equal(t, poolRunner(t, v), first)
+
+	// Health checks do not count.
+	for range 3 {
+		assert.NilError(t, v.Health(context.Background()))
+	}
+	assert.Equal(t, poolRunner(t, v), first)
+
+	_, err = v.Inspect("internal/testdata/valid-bag")
+	assert.NilError(t, err)
+	second := poolRunner(t, v)
+	assert.Assert(t, second != first)
+	assert.DeepEqual(t, v.Stats(), PoolStats{MinRunners: 1, MaxRunners: 1, Runners: 1, Idle: 1})
+
+	assert.NilError(t, v.Validate("internal/testdata/valid-bag"))
+	assert.Equal(t, poolRunner(t, v), second)
+	assert.Equal(t, second.runner.requests, 1)
+}
+
+func TestValidatorKeepsRunnersWithoutLimits(t *testing.T) {
+	t.Parallel()
+
+	v, err := NewValidator(WithPoolSize(1), WithTempCacheDir())
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, v.Close())
+	})
+
+	first := poolRunner(t, v)
+	for range 3 {
+		assert.NilError(t, v.Validate("internal/testdata/valid-bag"))
+	}
+	assert.Equal(t, poolRunner(t, v), first)
+	assert.Equal(t, first.runner.requests, 3)
+}
@@ -0,0 +1,53 @@
+package bagit
+
+// ValidationDetailType identifies the kind of problem described by a
+// ValidationDetail. Values match the bagit-python exception class names.
+type ValidationDetailType string
+
+const (
+	// ChecksumMismatch reports a payload or tag file whose computed checksum
+	// does not match the value recorded in the manifest.
+	ChecksumMismatch ValidationDetailType = "ChecksumMismatch"
+
+	// FileMissing reports a file listed in a manifest that was not found on
+	// the filesystem.
+	FileMissing ValidationDetailType = "FileMissing"
+
+	// UnexpectedFile reports a payload file found on the filesystem that is
+	// not listed in any manifest.
+	UnexpectedFile ValidationDetailType = "UnexpectedFile"
+
+	// FileNormalizationConflict reports two file names that differ only in
+	// their Unicode normalization form.
+	FileNormalizationConflict ValidationDetailType = "FileNormalizationConflict"
+)
+
+// ValidationDetail describes a single problem found while validating a bag.
+type ValidationDetail struct {
+	Type ValidationDetailType `json:"type"`
+
+	// Path is the file path relative to the bag root, e.g. "data/file.txt".
+	Path string `json:"path"`
+
+	// Algorithm, Expected and Found are only set for ChecksumMismatch.
+	Algorithm string `json:"algorithm,omitempty"`
+	Expected  string `json:"expected,omitempty"`
+	Found     string `json:"found,omitempty"`
+
+	// ConflictingPath is only set for FileNormalizationConflict. It is the
+	// file name that conflicts with Path.
+	ConflictingPath string `json:"conflicting_path,omitempty"`
+
+	// Message is the human-readable description given by bagit-python.
+	Message string `json:"message"`
+}
+
+// ValidationReport is the structured outcome of a failed validation.
+type ValidationReport struct {
+	// Message is the error message reported by bagit-python.
+	Message string
+
+	// Details lists every problem found, empty when bagit-python gave up
+	// before checking the manifests, e.g. when bagit.txt is missing.
+	Details []ValidationDetail
+}
@@ -0,0 +1,37 @@
+package bagit
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// processRSS returns the resident set size of process pid in bytes, read from
+// the VmRSS field of /proc/<pid>/status.
+func processRSS(pid int) (int64, error) {
+	blob, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return 0, err
+	}
+
+	s := bufio.NewScanner(bytes.NewReader(blob))
+	for s.Scan() {
+		value, ok := bytes.CutPrefix(s.Bytes(), []byte("VmRSS:"))
+		if !ok {
+			continue
+		}
+		kb, ok := bytes.CutSuffix(bytes.TrimSpace(value), []byte(" kB"))
+		if !ok {
+			return 0, fmt.Errorf("parse VmRSS: %q", value)
+		}
+		n, err := strconv.ParseInt(string(bytes.TrimSpace(kb)), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("parse VmRSS: %v", err)
+		}
+		return n * 1024, nil
+	}
+
+	return 0, fmt.Errorf("VmRSS not found in /proc/%d/status", pid)
+}
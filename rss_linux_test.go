@@ -0,0 +1,41 @@
+package bagit
+
+import (
+	"os"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestProcessRSS(t *testing.T) {
+	t.Parallel()
+
+	rss, err := processRSS(os.Getpid())
+	assert.NilError(t, err)
+	assert.Assert(t, rss > 1<<20)
+
+	_, err = processRSS(-1)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestValidatorRetiresRunnersAboveMaxRSS(t *testing.T) {
+	t.Parallel()
+
+	v, err := NewValidator(WithPoolSize(1), WithMaxRunnerRSS(1<<40), WithTempCacheDir())
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, v.Close())
+	})
+
+	first := poolRunner(t, v)
+	assert.NilError(t, v.Validate("internal/testdata/valid-bag"))
+	assert.Equal(t, poolRunner(t, v), first)
+	rss, err := first.runner.rss()
+	assert.NilError(t, err)
+	assert.Assert(t, rss > 0)
+
+	// Any Python process is above one byte.
+	v.maxRSS = 1
+	assert.NilError(t, v.Validate("internal/testdata/valid-bag"))
+	assert.Assert(t, poolRunner(t, v) != first)
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package bagit
+
+import "errors"
+
+// processRSS is not implemented outside Linux.
+func processRSS(pid int) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
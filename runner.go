@@ -3,17 +3,21 @@ package bagit
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os/exec"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/kluctl/go-embed-python/python"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // pyRunner manages the execution of the Python script wrapping bagit-python.
@@ -22,60 +26,127 @@ import (
 type pyRunner struct {
 	py           *python.EmbeddedPython // Instance of EmbeddedPython.
 	entryPoint   string                 // Path to the runner wrapper entry point.
+	cfg          runnerConfig           // Restart policy and logger.
+	logger       *slog.Logger           // Runner events, discarded by default.
+	tracer       trace.Tracer           // Spans of commands, none by default.
 	cmd          *exec.Cmd              // Command running Python interpreter.
 	running      atomic.Bool            // Tracks whether the command is still running.
 	wg           sync.WaitGroup         // Tracks the cmd monitor goroutine.
 	stdin        io.WriteCloser         // Standard input stream.
 	stdout       io.ReadCloser          // Standard output stream.
 	stdoutReader *bufio.Reader          // Standard output stream (buffered reader).
+	stderr       *lineTail              // Last lines written to standard error.
+	failures     int                    // Consecutive crashes since the last response.
+	stopping     atomic.Bool            // Set when the process is asked to exit or killed.
+	requests     int                    // Commands answered by the current process, except pings.
+	starts       int                    // Processes started.
+	lastCrash    time.Time              // Time of the last crash.
+	lastErr      error                  // Error returned for the last crash.
 	mu           sync.Mutex             // Prevents sharing the command (see ErrBusy).
 }
 
-func createRunner(py *python.EmbeddedPython, entryPoint string) *pyRunner {
+// runnerConfig configures how a pyRunner recovers from crashes, how long its
+// commands may run and where its output is logged.
+type runnerConfig struct {
+	maxRestarts    int                  // Consecutive restarts allowed after crashes, 0 means no limit.
+	logger         *slog.Logger         // Receives the runner output, nil discards it.
+	requestTimeout time.Duration        // Maximum duration of a command, 0 means no limit.
+	onRestart      func()               // Called when a process is started again, may be nil.
+	tracerProvider trace.TracerProvider // Records the spans of commands, nil records nothing.
+	extractLimits  extractLimits        // Bounds the extraction of serialized bags.
+}
+
+const (
+	stderrTailLines    = 20                     // Stderr lines kept for crash reports.
+	restartBackoffBase = 100 * time.Millisecond // Delay before the first restart.
+	restartBackoffMax  = 5 * time.Second        // Upper bound of the restart delay.
+	crashExitTimeout   = time.Second            // Wait for a failing process to exit.
+)
+
+func createRunner(py *python.EmbeddedPython, entryPoint string, cfg runnerConfig) *pyRunner {
+	logger := cfg.logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+
 	return &pyRunner{
 		py:         py,
 		entryPoint: entryPoint,
+		cfg:        cfg,
+		logger:     logger,
+		tracer:     cfg.tracer(),
+		stderr:     newLineTail(stderrTailLines),
 	}
 }
 
 // ensure that the process is running.
-func (r *pyRunner) ensure() error {
+//
+// After a crash, ensure waits for an exponential backoff before starting a new
+// process, and fails once the configured number of consecutive restarts has
+// been exceeded.
+func (r *pyRunner) ensure(ctx context.Context) error {
 	if r.running.Load() {
 		return nil
 	}
 
+	if r.failures > 0 {
+		if r.cfg.maxRestarts > 0 && r.failures > r.cfg.maxRestarts {
+			return &RunnerError{
+				Message: fmt.Sprintf("runner crashed %d times in a row, giving up", r.failures),
+				Err:     r.lastErr,
+			}
+		}
+		if err := sleep(ctx, r.backoff()); err != nil {
+			return err
+		}
+	}
+
 	var err error
+	r.stopping.Store(false)
 	r.cmd, err = r.py.PythonCmd(r.entryPoint)
 	if err != nil {
-		return fmt.Errorf("start runner: %v", err)
+		return &RunnerError{Message: "start runner", Err: err}
 	}
 	r.cmd.Env = withEnv(r.cmd.Env, "PYTHONDONTWRITEBYTECODE=1")
-
-	// Useful for debugging the Python application.
-	// r.cmd.Stderr = os.Stderr
+	setProcessGroup(r.cmd)
+
+	r.stderr.reset()
+	r.cmd.Stderr = r.stderr
+	if r.cfg.logger != nil {
+		// Ask the runner to forward Python logging records as JSON lines.
+		r.cmd.Env = withEnv(r.cmd.Env, "BAGIT_RUNNER_LOG_LEVEL="+pythonLogLevel(r.cfg.logger))
+		r.cmd.Stderr = io.MultiWriter(r.stderr, newLogWriter(r.cfg.logger))
+	}
 
 	r.stdin, err = r.cmd.StdinPipe()
 	if err != nil {
-		return fmt.Errorf("create stdin pipe: %v", err)
+		return &RunnerError{Message: "create stdin pipe", Err: err}
 	}
 
 	r.stdout, err = r.cmd.StdoutPipe()
 	if err != nil {
-		return fmt.Errorf("create stdout pipe: %v", err)
+		return &RunnerError{Message: "create stdout pipe", Err: err}
 	}
 	r.stdoutReader = bufio.NewReader(r.stdout)
 
 	err = r.cmd.Start()
 	if err != nil {
-		return fmt.Errorf("start cmd: %v", err)
+		return &RunnerError{Message: "start cmd", Err: err}
 	}
 
 	r.running.Store(true)
+	r.requests = 0
+	r.logger.Debug("runner started", "pid", r.cmd.Process.Pid)
+	if r.starts++; r.starts > 1 && r.cfg.onRestart != nil {
+		r.cfg.onRestart()
+	}
 
 	// Monitor the command from a dedicated goroutine.
+	cmd := r.cmd
 	r.wg.Go(func() {
-		_ = r.cmd.Wait()
+		_ = cmd.Wait()
 		r.running.Store(false)
+		r.exited(cmd)
 	})
 
 	return nil
@@ -84,36 +155,112 @@ func (r *pyRunner) ensure() error {
 type cmd struct {
 	Name string `json:"name"` // Name of the command, e.g.: "validate", "make", etc...
 	Args any    `json:"args"` // Payload, e.g. &validateRequest{}.
+
+	// W3C trace context of the command, the runner reports the spans of its
+	// phases when it is set.
+	Trace map[string]string `json:"trace,omitempty"`
 }
 
-// send a command to the runner.
-func (r *pyRunner) send(name string, args any) ([]byte, error) {
+// send a command to the runner. Progress events written by the runner before
+// the response are passed to progress, which may be nil.
+//
+// If ctx is done or the request timeout expires before the response is
+// received, the runner process is killed and send returns ctx.Err() or
+// ErrTimeout. The next command starts a new process.
+func (r *pyRunner) send(ctx context.Context, name string, args any, progress ProgressFunc) (_ []byte, err error) {
 	if ok := r.mu.TryLock(); !ok {
 		return nil, ErrBusy
 	}
 	defer r.mu.Unlock()
 
-	if err := r.ensure(); err != nil {
+	ctx, span := r.tracer.Start(ctx, "bagit.runner."+name, trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { endSpan(span, err) }()
+
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
-	cmd := cmd{Name: name, Args: args}
+	cmd := cmd{Name: name, Args: args, Trace: traceContext(ctx)}
 	blob, err := json.Marshal(cmd)
 	if err != nil {
-		return nil, fmt.Errorf("encode args: %v", err)
+		return nil, &RunnerError{Message: "encode args", Err: err}
 	}
 	blob = append(blob, '\n')
 
-	_, err = r.stdin.Write(blob)
+	if err := r.ensure(ctx); err != nil {
+		return nil, err
+	}
+
+	// The request timeout starts once the process is spawned, so that it does
+	// not include the restart backoff.
+	if r.cfg.requestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, r.cfg.requestTimeout, ErrTimeout)
+		defer cancel()
+	}
+
+	killed := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		defer close(killed)
+		_ = r.kill()
+	})
+
+	resp, err := r.roundTrip(blob, progress)
+
+	if !stop() {
+		// The process was killed, wait until it is gone so that the next
+		// command starts a new one.
+		<-killed
+		r.wg.Wait()
+		r.releasePipes()
+		return nil, doneErr(ctx)
+	}
+
+	if err != nil {
+		return nil, r.crashed(err)
+	}
+	r.failures = 0
+	if name != "ping" {
+		r.requests++
+	}
+
+	return resp, nil
+}
+
+// roundTrip writes an encoded command to the runner and reads its response,
+// passing the progress events that precede it to progress and recording the
+// spans reported by the runner.
+func (r *pyRunner) roundTrip(blob []byte, progress ProgressFunc) ([]byte, error) {
+	_, err := r.stdin.Write(blob)
 	if err != nil {
-		return nil, fmt.Errorf("write blob: %v", err)
+		return nil, &RunnerError{Message: "write blob", Err: err}
+	}
+
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if ok, err := readSpan(line, r.tracer); err != nil {
+			return nil, &RunnerError{Message: "decode span", Err: err}
+		} else if ok {
+			continue
+		}
+		if ok, err := readProgress(line, progress); err != nil {
+			return nil, &RunnerError{Message: "decode progress", Err: err}
+		} else if !ok {
+			return line, nil
+		}
 	}
+}
 
+// readLine reads a line written by the runner.
+func (r *pyRunner) readLine() ([]byte, error) {
 	line := bytes.NewBuffer(nil)
 	for {
 		l, p, err := r.stdoutReader.ReadLine()
 		if err != nil && err != io.EOF {
-			return nil, fmt.Errorf("read line: %v", err)
+			return nil, &RunnerError{Message: "read line", Err: err}
 		}
 		line.Write(l)
 		if !p {
@@ -121,12 +268,85 @@ func (r *pyRunner) send(name string, args any) ([]byte, error) {
 		}
 	}
 	if line.Len() < 1 {
-		return nil, fmt.Errorf("response not received")
+		return nil, &RunnerError{Message: "response not received"}
 	}
 
 	return line.Bytes(), nil
 }
 
+// crashed handles a failed round trip. The process is killed if it is still
+// running, and err is completed with its exit code and last stderr lines.
+func (r *pyRunner) crashed(err error) error {
+	if exited := wait(&r.wg, crashExitTimeout); !exited {
+		_ = r.kill()
+		r.wg.Wait()
+	}
+
+	r.releasePipes()
+
+	rerr, ok := err.(*RunnerError)
+	if !ok {
+		rerr = &RunnerError{Message: "runner crashed", Err: err}
+	}
+	rerr.Crashed = true
+	rerr.ExitCode = r.cmd.ProcessState.ExitCode()
+	rerr.Stderr = r.stderr.lines()
+	r.recordCrash(rerr)
+
+	return rerr
+}
+
+// exited records the crash of a process that exited while the runner was
+// idle, unless it was stopped. Crashes during a command are recorded by send.
+func (r *pyRunner) exited(cmd *exec.Cmd) {
+	if r.stopping.Load() {
+		return
+	}
+	if ok := r.mu.TryLock(); !ok {
+		return
+	}
+	defer r.mu.Unlock()
+	if r.cmd != cmd {
+		return
+	}
+
+	r.releasePipes()
+	r.recordCrash(&RunnerError{
+		Message:  "runner exited while idle",
+		Crashed:  true,
+		ExitCode: cmd.ProcessState.ExitCode(),
+		Stderr:   r.stderr.lines(),
+	})
+}
+
+// recordCrash counts a crash towards the restart policy.
+func (r *pyRunner) recordCrash(rerr *RunnerError) {
+	r.failures++
+	r.lastCrash = time.Now()
+	r.lastErr = rerr
+
+	r.logger.Warn("runner crashed", "exit_code", rerr.ExitCode, "failures", r.failures, "err", rerr)
+}
+
+// releasePipes drops the pipes of an exited process. They have already been
+// closed by exec.Cmd.Wait, ensure creates new ones for the next process.
+func (r *pyRunner) releasePipes() {
+	r.stdin = nil
+	r.stdout = nil
+	r.stdoutReader = nil
+}
+
+// backoff returns how long to wait before restarting a crashed runner. The
+// delay doubles with every consecutive crash, up to restartBackoffMax.
+func (r *pyRunner) backoff() time.Duration {
+	d := restartBackoffMax
+	if r.failures < 16 {
+		d = min(restartBackoffBase<<(r.failures-1), restartBackoffMax)
+	}
+
+	return d - time.Since(r.lastCrash)
+}
+
 // quit requests the runner to exit gracefully.
 func (r *pyRunner) quit() error {
 	if !r.running.Load() {
@@ -140,12 +360,22 @@ func (r *pyRunner) quit() error {
 
 	var err error
 	if r.stdin != nil {
+		r.stopping.Store(true)
 		_, err = r.stdin.Write([]byte(`{"name":"exit"}` + "\n"))
 	}
 
 	return err
 }
 
+// rss returns the resident set size of the runner process in bytes.
+func (r *pyRunner) rss() (int64, error) {
+	if !r.running.Load() {
+		return 0, nil
+	}
+
+	return processRSS(r.cmd.Process.Pid)
+}
+
 func (r *pyRunner) stop() error {
 	var e error
 
@@ -155,7 +385,7 @@ func (r *pyRunner) stop() error {
 
 	// Wait up to a second, otherwise force to exit immediately.
 	if closed := wait(&r.wg, time.Second); !closed {
-		if err := r.cmd.Process.Kill(); err != nil {
+		if err := r.kill(); err != nil {
 			e = errors.Join(e, err)
 		}
 	}
@@ -165,6 +395,43 @@ func (r *pyRunner) stop() error {
 	return e
 }
 
+// kill terminates the runner process and its multiprocessing workers.
+func (r *pyRunner) kill() error {
+	if !r.running.Load() {
+		return nil
+	}
+
+	r.stopping.Store(true)
+	return killProcessGroup(r.cmd)
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return doneErr(ctx)
+	}
+}
+
+// doneErr returns why ctx is done: ErrTimeout if the request timeout of the
+// runner expired, ctx.Err() otherwise.
+func doneErr(ctx context.Context) error {
+	if errors.Is(context.Cause(ctx), ErrTimeout) {
+		return ErrTimeout
+	}
+
+	return ctx.Err()
+}
+
 func wait(wg *sync.WaitGroup, timeout time.Duration) bool {
 	done := make(chan struct{})
 	go func() {
@@ -195,3 +462,61 @@ func withEnv(env []string, kv string) []string {
 
 	return append(env, kv)
 }
+
+// lineTail is an io.Writer that keeps the last lines written to it.
+type lineTail struct {
+	mu      sync.Mutex
+	max     int
+	buf     []string
+	partial []byte
+}
+
+func newLineTail(max int) *lineTail {
+	return &lineTail{max: max}
+}
+
+func (t *lineTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.partial = append(t.partial, p...)
+	for {
+		i := bytes.IndexByte(t.partial, '\n')
+		if i < 0 {
+			break
+		}
+		t.add(string(bytes.TrimRight(t.partial[:i], "\r")))
+		t.partial = t.partial[i+1:]
+	}
+
+	return len(p), nil
+}
+
+func (t *lineTail) add(line string) {
+	if len(t.buf) == t.max {
+		copy(t.buf, t.buf[1:])
+		t.buf = t.buf[:t.max-1]
+	}
+	t.buf = append(t.buf, line)
+}
+
+// lines returns the kept lines, including an unterminated last line.
+func (t *lineTail) lines() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	lines := slices.Clone(t.buf)
+	if len(t.partial) > 0 {
+		lines = append(lines, string(t.partial))
+	}
+
+	return lines
+}
+
+func (t *lineTail) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.buf = t.buf[:0]
+	t.partial = nil
+}
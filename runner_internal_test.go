@@ -0,0 +1,156 @@
+package bagit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/poll"
+)
+
+func newTestRuntime(t *testing.T) *bagItRuntime {
+	t.Helper()
+
+	rt, err := newBagItRuntime(bagItRuntimeConfig{})
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, rt.cleanup())
+	})
+
+	return rt
+}
+
+func newTestRunner(t *testing.T, rt *bagItRuntime, script string, cfg runnerConfig) *pyRunner {
+	t.Helper()
+
+	entryPoint := filepath.Join(rt.embedRunner.GetExtractedPath(), "main.py")
+	if script != "" {
+		entryPoint = filepath.Join(t.TempDir(), "main.py")
+		assert.NilError(t, os.WriteFile(entryPoint, []byte(script), 0o600))
+	}
+
+	r := createRunner(rt.embedPython, entryPoint, cfg)
+	t.Cleanup(func() {
+		assert.NilError(t, r.stop())
+	})
+
+	return r
+}
+
+func TestRunnerReportsCrashes(t *testing.T) {
+	rt := newTestRuntime(t)
+	r := newTestRunner(t, rt, `import sys
+sys.stdin.readline()
+print("Traceback (most recent call last):", file=sys.stderr)
+print("RuntimeError: boom", file=sys.stderr)
+sys.exit(3)
+`, runnerConfig{maxRestarts: 1})
+
+	_, err := r.send(context.Background(), "validate", &validateRequest{}, nil)
+	var rerr *RunnerError
+	assert.Assert(t, errors.As(err, &rerr))
+	assert.Assert(t, rerr.Crashed)
+	assert.Equal(t, rerr.ExitCode, 3)
+	assert.DeepEqual(t, rerr.Stderr, []string{
+		"Traceback (most recent call last):",
+		"RuntimeError: boom",
+	})
+	assert.Error(t, err, "response not received (runner exit code 3): RuntimeError: boom")
+	assert.Assert(t, !r.running.Load())
+	assert.Assert(t, r.stdin == nil)
+
+	// The runner is restarted once, then gives up.
+	_, err = r.send(context.Background(), "validate", &validateRequest{}, nil)
+	assert.Assert(t, errors.As(err, &rerr))
+	assert.Assert(t, rerr.Crashed)
+
+	_, err = r.send(context.Background(), "validate", &validateRequest{}, nil)
+	assert.ErrorContains(t, err, "runner crashed 2 times in a row, giving up")
+	assert.Assert(t, errors.As(err, &rerr))
+	assert.Assert(t, !rerr.Crashed)
+}
+
+func TestRunnerRecordsIdleCrashes(t *testing.T) {
+	rt := newTestRuntime(t)
+	r := newTestRunner(t, rt, `import sys
+sys.stdin.readline()
+print('{"valid": true}', flush=True)
+print("MemoryError", file=sys.stderr)
+sys.exit(4)
+`, runnerConfig{})
+
+	_, err := r.send(context.Background(), "validate", &validateRequest{}, nil)
+	assert.NilError(t, err)
+
+	poll.WaitOn(t, func(poll.LogT) poll.Result {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.failures == 0 {
+			return poll.Continue("crash not recorded")
+		}
+		return poll.Success()
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	assert.Equal(t, r.failures, 1)
+	var rerr *RunnerError
+	assert.Assert(t, errors.As(r.lastErr, &rerr))
+	assert.Assert(t, rerr.Crashed)
+	assert.Equal(t, rerr.ExitCode, 4)
+	assert.DeepEqual(t, rerr.Stderr, []string{"MemoryError"})
+}
+
+func TestRunnerStopIsNotACrash(t *testing.T) {
+	rt := newTestRuntime(t)
+	r := newTestRunner(t, rt, "", runnerConfig{})
+
+	_, err := r.send(context.Background(), "validate", &validateRequest{Path: "internal/testdata/valid-bag"}, nil)
+	assert.NilError(t, err)
+	assert.NilError(t, r.stop())
+	assert.Equal(t, r.failures, 0)
+	assert.Assert(t, r.lastErr == nil)
+}
+
+func TestRunnerRestartsAfterCrash(t *testing.T) {
+	rt := newTestRuntime(t)
+	r := newTestRunner(t, rt, "", runnerConfig{})
+
+	_, err := r.send(context.Background(), "validate", &validateRequest{Path: "internal/testdata/valid-bag"}, nil)
+	assert.NilError(t, err)
+
+	// Kill the process while it is idle.
+	assert.NilError(t, r.kill())
+	r.wg.Wait()
+
+	blob, err := r.send(context.Background(), "validate", &validateRequest{Path: "internal/testdata/valid-bag"}, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, string(blob), `{"valid": true}`)
+	assert.Equal(t, r.failures, 0)
+}
+
+func TestRunnerBackoffIsBounded(t *testing.T) {
+	r := &pyRunner{}
+
+	r.failures = 1
+	assert.Assert(t, r.backoff() <= restartBackoffBase)
+
+	r.failures = 100
+	assert.Assert(t, r.backoff() <= restartBackoffMax)
+}
+
+func TestLineTail(t *testing.T) {
+	tail := newLineTail(2)
+
+	_, _ = tail.Write([]byte("one\ntwo\r\nthr"))
+	assert.DeepEqual(t, tail.lines(), []string{"one", "two", "thr"})
+
+	_, _ = tail.Write([]byte("ee\nfour\n"))
+	assert.DeepEqual(t, tail.lines(), []string{"three", "four"})
+
+	tail.reset()
+	assert.Equal(t, len(tail.lines()), 0)
+}
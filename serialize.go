@@ -0,0 +1,300 @@
+package bagit
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// serializeModTime is the modification time recorded for every archive entry,
+// so that serializing the same bag twice produces identical archives. It is
+// the earliest time supported by zip.
+var serializeModTime = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// SerializeOption configures Serialize.
+type SerializeOption func(*serializeConfig)
+
+type serializeConfig struct {
+	checksum Algorithm
+}
+
+// WithChecksumFile writes a sidecar file next to the archive holding its
+// checksum computed with alg, named after the archive plus the algorithm,
+// e.g. "bag.tar.gz.sha256". The file uses the format of sha256sum and similar
+// tools: the hex-encoded checksum, two spaces and the archive file name.
+func WithChecksumFile(alg Algorithm) SerializeOption {
+	return func(cfg *serializeConfig) {
+		cfg.checksum = alg
+	}
+}
+
+// Serialize writes the bag at bagPath to the archive dest, see
+// SerializeContext.
+func Serialize(bagPath, dest string, format Format, opts ...SerializeOption) error {
+	return SerializeContext(context.Background(), bagPath, dest, format, opts...)
+}
+
+// SerializeContext writes the bag at bagPath to the archive dest in the given
+// format. The archive holds a single top-level directory named after the bag,
+// as required by RFC 8493 section 4.2.
+//
+// Archives are reproducible: entries are sorted by path and recorded with a
+// fixed modification time, fixed permissions and no owner, so serializing the
+// same bag twice produces the same archive and checksum. Only regular files
+// and directories are supported.
+//
+// The archive is written to a temporary file in the directory of dest, which
+// is renamed once complete. If ctx is done first, the temporary file is
+// removed and SerializeContext returns ctx.Err().
+func SerializeContext(ctx context.Context, bagPath, dest string, format Format, opts ...SerializeOption) (err error) {
+	cfg := serializeConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var h hash.Hash
+	if cfg.checksum != "" {
+		h, err = newHash(cfg.checksum)
+		if err != nil {
+			return fmt.Errorf("serialize: %v", err)
+		}
+	}
+
+	bagPath, err = filepath.Abs(bagPath)
+	if err != nil {
+		return fmt.Errorf("serialize: %v", err)
+	}
+	if st, err := os.Stat(bagPath); err != nil {
+		return fmt.Errorf("serialize: %w", err)
+	} else if !st.IsDir() {
+		return fmt.Errorf("serialize: %s is not a directory", bagPath)
+	}
+	if abs, err := filepath.Abs(dest); err != nil {
+		return fmt.Errorf("serialize: %v", err)
+	} else if rel, err := filepath.Rel(bagPath, abs); err == nil && filepath.IsLocal(rel) {
+		return fmt.Errorf("serialize: destination %s is inside the bag", dest)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(dest), "."+filepath.Base(dest)+"-*")
+	if err != nil {
+		return fmt.Errorf("serialize: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	var w io.Writer = f
+	if h != nil {
+		w = io.MultiWriter(f, h)
+	}
+
+	s := &serializer{ctx: ctx, root: bagPath, top: filepath.Base(bagPath)}
+	switch format {
+	case FormatZip:
+		err = s.zip(w)
+	case FormatTar:
+		err = s.tar(w)
+	case FormatTarGzip:
+		err = s.tarGzip(w)
+	case FormatTarZstd:
+		err = s.tarZstd(w)
+	default:
+		err = fmt.Errorf("unsupported format: %q", format)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("serialize: %w", err)
+	}
+
+	if err := f.Chmod(0o644); err != nil {
+		return fmt.Errorf("serialize: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("serialize: %v", err)
+	}
+	if err := os.Rename(f.Name(), dest); err != nil {
+		return fmt.Errorf("serialize: %v", err)
+	}
+
+	if h != nil {
+		sum := fmt.Sprintf("%s  %s\n", hex.EncodeToString(h.Sum(nil)), filepath.Base(dest))
+		if err := os.WriteFile(dest+"."+string(cfg.checksum), []byte(sum), 0o644); err != nil {
+			return fmt.Errorf("serialize: write checksum file: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func newHash(alg Algorithm) (hash.Hash, error) {
+	switch alg {
+	case MD5:
+		return md5.New(), nil
+	case SHA1:
+		return sha1.New(), nil
+	case SHA224:
+		return sha256.New224(), nil
+	case SHA256:
+		return sha256.New(), nil
+	case SHA384:
+		return sha512.New384(), nil
+	case SHA512:
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported checksum algorithm: %q", alg)
+	}
+}
+
+// serializer writes the files below root into an archive, under the top-level
+// directory top.
+type serializer struct {
+	ctx  context.Context
+	root string
+	top  string
+}
+
+// walk calls fn for every directory and regular file of the bag in lexical
+// order, with its slash-separated archive name.
+func (s *serializer) walk(fn func(name, path string, d fs.DirEntry) error) error {
+	return filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := s.ctx.Err(); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(s.root, path)
+		if err != nil {
+			return err
+		}
+		name := s.top
+		if rel != "." {
+			name += "/" + filepath.ToSlash(rel)
+		}
+
+		if !d.IsDir() && !d.Type().IsRegular() {
+			return fmt.Errorf("unsupported file type: %s", path)
+		}
+
+		return fn(name, path, d)
+	})
+}
+
+func (s *serializer) tar(w io.Writer) error {
+	tw := tar.NewWriter(w)
+
+	err := s.walk(func(name, path string, d fs.DirEntry) error {
+		hdr := &tar.Header{
+			Typeflag: tar.TypeDir,
+			Name:     name + "/",
+			Mode:     0o755,
+			ModTime:  serializeModTime,
+		}
+		if d.IsDir() {
+			return tw.WriteHeader(hdr)
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		hdr.Typeflag = tar.TypeReg
+		hdr.Name = name
+		hdr.Mode = 0o644
+		hdr.Size = info.Size()
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		return s.copy(tw, path)
+	})
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
+
+func (s *serializer) tarGzip(w io.Writer) error {
+	zw := gzip.NewWriter(w)
+	if err := s.tar(zw); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+func (s *serializer) tarZstd(w io.Writer) error {
+	zw, err := zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return err
+	}
+	if err := s.tar(zw); err != nil {
+		zw.Close()
+		return err
+	}
+
+	return zw.Close()
+}
+
+func (s *serializer) zip(w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	err := s.walk(func(name, path string, d fs.DirEntry) error {
+		hdr := &zip.FileHeader{Name: name + "/", Modified: serializeModTime}
+		if d.IsDir() {
+			hdr.SetMode(fs.ModeDir | 0o755)
+			_, err := zw.CreateHeader(hdr)
+			return err
+		}
+
+		hdr.Name = name
+		hdr.Method = zip.Deflate
+		hdr.SetMode(0o644)
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+
+		return s.copy(fw, path)
+	})
+	if err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// copy writes the contents of the file at path to w.
+func (s *serializer) copy(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, ctxReader{ctx: s.ctx, r: f})
+
+	return err
+}
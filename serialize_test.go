@@ -0,0 +1,99 @@
+package bagit_test
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/artefactual-labs/bagit-gython"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+func TestSerialize(t *testing.T) {
+	t.Parallel()
+
+	b := setUp(t)
+
+	tmpDir := fs.NewDir(t, "", fs.WithDir("transfer", fs.WithFile("test.txt", "abcd"), fs.WithDir("sub", fs.WithFile("other.txt", "ef"))))
+	bagPath := tmpDir.Join("transfer")
+	_, err := b.Make(bagPath, bagit.MakeOptions{})
+	assert.NilError(t, err)
+
+	for _, format := range []bagit.Format{bagit.FormatZip, bagit.FormatTar, bagit.FormatTarGzip, bagit.FormatTarZstd} {
+		t.Run("Serializes "+string(format), func(t *testing.T) {
+			out := fs.NewDir(t, "")
+			dest := out.Join("bag." + string(format))
+
+			err := bagit.Serialize(bagPath, dest, format, bagit.WithChecksumFile(bagit.SHA256))
+			assert.NilError(t, err)
+			assert.NilError(t, b.Validate(dest))
+
+			blob, err := os.ReadFile(dest)
+			assert.NilError(t, err)
+			sum := sha256.Sum256(blob)
+			sidecar, err := os.ReadFile(dest + ".sha256")
+			assert.NilError(t, err)
+			assert.Equal(t, string(sidecar), hex.EncodeToString(sum[:])+"  bag."+string(format)+"\n")
+
+			// Serializing again produces the same archive.
+			again := out.Join("again")
+			assert.NilError(t, bagit.Serialize(bagPath, again, format))
+			blob2, err := os.ReadFile(again)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, blob, blob2)
+		})
+	}
+
+	t.Run("Writes a single top-level directory in lexical order", func(t *testing.T) {
+		dest := filepath.Join(t.TempDir(), "bag.tar")
+		assert.NilError(t, bagit.Serialize(bagPath, dest, bagit.FormatTar))
+
+		f, err := os.Open(dest)
+		assert.NilError(t, err)
+		defer f.Close()
+
+		var names []string
+		tr := tar.NewReader(f)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, hdr.ModTime.Year(), 1980)
+			names = append(names, hdr.Name)
+		}
+		assert.DeepEqual(t, names, []string{
+			"transfer/",
+			"transfer/bag-info.txt",
+			"transfer/bagit.txt",
+			"transfer/data/",
+			"transfer/data/sub/",
+			"transfer/data/sub/other.txt",
+			"transfer/data/test.txt",
+			"transfer/manifest-sha256.txt",
+			"transfer/manifest-sha512.txt",
+			"transfer/tagmanifest-sha256.txt",
+			"transfer/tagmanifest-sha512.txt",
+		})
+	})
+
+	t.Run("Rejects unsupported formats", func(t *testing.T) {
+		dest := filepath.Join(t.TempDir(), "bag.rar")
+		err := bagit.Serialize(bagPath, dest, bagit.Format("rar"))
+		assert.Error(t, err, `serialize: unsupported format: "rar"`)
+
+		_, err = os.Stat(dest)
+		assert.Assert(t, os.IsNotExist(err))
+	})
+
+	t.Run("Rejects destinations inside the bag", func(t *testing.T) {
+		err := bagit.Serialize(bagPath, filepath.Join(bagPath, "bag.zip"), bagit.FormatZip)
+		assert.ErrorContains(t, err, "is inside the bag")
+	})
+}
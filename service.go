@@ -0,0 +1,218 @@
+package bagit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// BagValidator validates bags. *Validator, *BagIt and the fake of package
+// bagittest implement it.
+type BagValidator interface {
+	ValidateContext(ctx context.Context, path string, opts ...ValidateOption) error
+}
+
+// Service is the set of commands shared by *Validator and *BagIt, to swap
+// implementations, mock them or wrap them with Intercept.
+type Service interface {
+	BagValidator
+	MakeContext(ctx context.Context, path string, opts MakeOptions) (MakeResult, error)
+	UpdateContext(ctx context.Context, path string, opts UpdateOptions) error
+	InspectContext(ctx context.Context, path string) (*Bag, error)
+	ValidateProfileContext(ctx context.Context, path string, profile *Profile) error
+	CompleteContext(ctx context.Context, path string, fetcher Fetcher, opts CompleteOptions) error
+}
+
+var (
+	_ Service = (*Validator)(nil)
+	_ Service = (*BagIt)(nil)
+)
+
+// Command identifies a command of a Service passed to an Interceptor.
+type Command struct {
+	// Name is the name of the Service method without its Context suffix,
+	// e.g. "Validate" or "Make".
+	Name string
+
+	// Path is the bag path given to the command.
+	Path string
+}
+
+// Interceptor runs around the commands of a Service wrapped with Intercept.
+// It calls next to run the command, possibly more than once, e.g. to retry
+// it, and returns the error of the command or its own.
+type Interceptor func(ctx context.Context, cmd Command, next func(ctx context.Context) error) error
+
+// Intercept returns a Service running the commands of s through the
+// interceptors, the first one being the outermost.
+func Intercept(s Service, interceptors ...Interceptor) Service {
+	return &interceptedService{s: s, intercept: chainInterceptors(interceptors)}
+}
+
+func chainInterceptors(interceptors []Interceptor) Interceptor {
+	return func(ctx context.Context, cmd Command, next func(ctx context.Context) error) error {
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, inner := interceptors[i], next
+			next = func(ctx context.Context) error {
+				return interceptor(ctx, cmd, inner)
+			}
+		}
+		return next(ctx)
+	}
+}
+
+type interceptedService struct {
+	s         Service
+	intercept Interceptor
+}
+
+func (is *interceptedService) ValidateContext(ctx context.Context, path string, opts ...ValidateOption) error {
+	return is.intercept(ctx, Command{Name: "Validate", Path: path}, func(ctx context.Context) error {
+		return is.s.ValidateContext(ctx, path, opts...)
+	})
+}
+
+func (is *interceptedService) MakeContext(ctx context.Context, path string, opts MakeOptions) (res MakeResult, err error) {
+	err = is.intercept(ctx, Command{Name: "Make", Path: path}, func(ctx context.Context) (err error) {
+		res, err = is.s.MakeContext(ctx, path, opts)
+		return err
+	})
+
+	return res, err
+}
+
+func (is *interceptedService) UpdateContext(ctx context.Context, path string, opts UpdateOptions) error {
+	return is.intercept(ctx, Command{Name: "Update", Path: path}, func(ctx context.Context) error {
+		return is.s.UpdateContext(ctx, path, opts)
+	})
+}
+
+func (is *interceptedService) InspectContext(ctx context.Context, path string) (bag *Bag, err error) {
+	err = is.intercept(ctx, Command{Name: "Inspect", Path: path}, func(ctx context.Context) (err error) {
+		bag, err = is.s.InspectContext(ctx, path)
+		return err
+	})
+
+	return bag, err
+}
+
+func (is *interceptedService) ValidateProfileContext(ctx context.Context, path string, profile *Profile) error {
+	return is.intercept(ctx, Command{Name: "ValidateProfile", Path: path}, func(ctx context.Context) error {
+		return is.s.ValidateProfileContext(ctx, path, profile)
+	})
+}
+
+func (is *interceptedService) CompleteContext(ctx context.Context, path string, fetcher Fetcher, opts CompleteOptions) error {
+	return is.intercept(ctx, Command{Name: "Complete", Path: path}, func(ctx context.Context) error {
+		return is.s.CompleteContext(ctx, path, fetcher, opts)
+	})
+}
+
+// LoggingInterceptor logs every command with its path, duration and error:
+// at info level when it succeeds, at warning level when the bag is invalid
+// and at error level otherwise.
+func LoggingInterceptor(logger *slog.Logger) Interceptor {
+	return func(ctx context.Context, cmd Command, next func(ctx context.Context) error) error {
+		start := time.Now()
+		err := next(ctx)
+
+		level := slog.LevelInfo
+		attrs := []slog.Attr{
+			slog.String("command", cmd.Name),
+			slog.String("path", cmd.Path),
+			slog.Duration("duration", time.Since(start)),
+		}
+		if err != nil {
+			level = slog.LevelError
+			if errors.Is(err, ErrInvalid) {
+				level = slog.LevelWarn
+			}
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		logger.LogAttrs(ctx, level, "bagit command", attrs...)
+
+		return err
+	}
+}
+
+// RetryPolicy configures RetryInterceptor.
+type RetryPolicy struct {
+	// Attempts is the maximum number of times a command runs. Defaults to 3.
+	Attempts int
+
+	// Backoff is the delay before the first retry, doubled before every
+	// following one. Defaults to 100ms.
+	Backoff time.Duration
+
+	// Retryable reports whether a failed command should be retried.
+	// Defaults to retrying ErrBusy, and crashes of the runner process during
+	// the read-only Validate, Inspect and ValidateProfile commands. Crashes
+	// during Make, Update and Complete are not retried since they may have
+	// left the bag partially changed. Invalid bags and context errors are
+	// never retried.
+	Retryable func(err error) bool
+}
+
+// RetryInterceptor runs failed commands again as configured by p. It stops
+// waiting and returns the last error when the context is done.
+func RetryInterceptor(p RetryPolicy) Interceptor {
+	if p.Attempts <= 0 {
+		p.Attempts = 3
+	}
+	if p.Backoff <= 0 {
+		p.Backoff = 100 * time.Millisecond
+	}
+
+	return func(ctx context.Context, cmd Command, next func(ctx context.Context) error) error {
+		retry := p.Retryable
+		if retry == nil {
+			retry = func(err error) bool {
+				return retryable(cmd, err)
+			}
+		}
+
+		backoff := p.Backoff
+		for attempt := 1; ; attempt++ {
+			err := next(ctx)
+			if err == nil || attempt >= p.Attempts || ctx.Err() != nil || !retry(err) {
+				return err
+			}
+
+			t := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return err
+			case <-t.C:
+			}
+			backoff *= 2
+		}
+	}
+}
+
+// retryable is the default RetryPolicy.Retryable for cmd.
+func retryable(cmd Command, err error) bool {
+	if errors.Is(err, ErrBusy) {
+		return true
+	}
+	switch cmd.Name {
+	case "Validate", "Inspect", "ValidateProfile":
+	default:
+		return false
+	}
+	var rerr *RunnerError
+	return errors.As(err, &rerr) && rerr.Crashed
+}
+
+// MetricsInterceptor calls observe after every command with its duration and
+// error, e.g. to record them in a metrics system.
+func MetricsInterceptor(observe func(ctx context.Context, cmd Command, d time.Duration, err error)) Interceptor {
+	return func(ctx context.Context, cmd Command, next func(ctx context.Context) error) error {
+		start := time.Now()
+		err := next(ctx)
+		observe(ctx, cmd, time.Since(start), err)
+
+		return err
+	}
+}
@@ -0,0 +1,154 @@
+package bagit_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/artefactual-labs/bagit-gython"
+	"github.com/artefactual-labs/bagit-gython/bagittest"
+	"gotest.tools/v3/assert"
+)
+
+func TestIntercept(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Runs interceptors in order around every command", func(t *testing.T) {
+		var fake bagittest.Fake
+		fake.On("bag", bagittest.Response{MakeResult: bagit.MakeResult{Version: "1.0"}})
+
+		var trace []string
+		record := func(name string) bagit.Interceptor {
+			return func(ctx context.Context, cmd bagit.Command, next func(ctx context.Context) error) error {
+				trace = append(trace, name+" "+cmd.Name+" "+cmd.Path)
+				return next(ctx)
+			}
+		}
+		s := bagit.Intercept(&fake, record("outer"), record("inner"))
+
+		res, err := s.MakeContext(context.Background(), "bag", bagit.MakeOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, res.Version, "1.0")
+		assert.NilError(t, s.ValidateContext(context.Background(), "bag"))
+		assert.NilError(t, s.UpdateContext(context.Background(), "bag", bagit.UpdateOptions{}))
+		bag, err := s.InspectContext(context.Background(), "bag")
+		assert.NilError(t, err)
+		assert.Equal(t, bag.Path, "bag")
+		assert.NilError(t, s.ValidateProfileContext(context.Background(), "bag", &bagit.Profile{}))
+		assert.NilError(t, s.CompleteContext(context.Background(), "bag", nil, bagit.CompleteOptions{}))
+
+		assert.DeepEqual(t, trace, []string{
+			"outer Make bag", "inner Make bag",
+			"outer Validate bag", "inner Validate bag",
+			"outer Update bag", "inner Update bag",
+			"outer Inspect bag", "inner Inspect bag",
+			"outer ValidateProfile bag", "inner ValidateProfile bag",
+			"outer Complete bag", "inner Complete bag",
+		})
+		assert.Equal(t, len(fake.Calls()), 6)
+	})
+
+	t.Run("Logs commands", func(t *testing.T) {
+		var fake bagittest.Fake
+		fake.On("invalid", bagittest.Response{Err: bagittest.Invalid("Payload-Oxum validation failed.")})
+		fake.On("broken", bagittest.Response{Err: &bagit.RunnerError{Message: "boom"}})
+
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey || a.Key == "duration" {
+					return slog.Attr{}
+				}
+				return a
+			},
+		}))
+		s := bagit.Intercept(&fake, bagit.LoggingInterceptor(logger))
+
+		assert.NilError(t, s.ValidateContext(context.Background(), "valid"))
+		assert.ErrorIs(t, s.ValidateContext(context.Background(), "invalid"), bagit.ErrInvalid)
+		assert.Error(t, s.ValidateContext(context.Background(), "broken"), "boom")
+
+		assert.DeepEqual(t, strings.Split(strings.TrimSpace(buf.String()), "\n"), []string{
+			`level=INFO msg="bagit command" command=Validate path=valid`,
+			`level=WARN msg="bagit command" command=Validate path=invalid error="invalid: Payload-Oxum validation failed."`,
+			`level=ERROR msg="bagit command" command=Validate path=broken error=boom`,
+		})
+	})
+
+	t.Run("Retries busy runners and crashes", func(t *testing.T) {
+		var fake bagittest.Fake
+		fake.On("bag",
+			bagittest.Response{Err: bagit.ErrBusy},
+			bagittest.Response{Err: &bagit.RunnerError{Message: "crash", Crashed: true}},
+			bagittest.Response{},
+		)
+		s := bagit.Intercept(&fake, bagit.RetryInterceptor(bagit.RetryPolicy{Backoff: time.Millisecond}))
+
+		assert.NilError(t, s.ValidateContext(context.Background(), "bag"))
+		assert.Equal(t, len(fake.Calls()), 3)
+	})
+
+	t.Run("Does not retry crashes of commands changing bags", func(t *testing.T) {
+		var fake bagittest.Fake
+		fake.OnAny(bagittest.Response{Err: &bagit.RunnerError{Message: "crash", Crashed: true}})
+		s := bagit.Intercept(&fake, bagit.RetryInterceptor(bagit.RetryPolicy{Backoff: time.Millisecond}))
+
+		_, err := s.MakeContext(context.Background(), "bag", bagit.MakeOptions{})
+		assert.ErrorContains(t, err, "crash")
+		assert.ErrorContains(t, s.UpdateContext(context.Background(), "bag", bagit.UpdateOptions{}), "crash")
+		assert.ErrorContains(t, s.CompleteContext(context.Background(), "bag", nil, bagit.CompleteOptions{}), "crash")
+		assert.Equal(t, len(fake.Calls()), 3)
+	})
+
+	t.Run("Does not retry invalid bags", func(t *testing.T) {
+		var fake bagittest.Fake
+		fake.OnAny(bagittest.Response{Err: bagittest.Invalid("Payload-Oxum validation failed.")})
+		s := bagit.Intercept(&fake, bagit.RetryInterceptor(bagit.RetryPolicy{Backoff: time.Millisecond}))
+
+		assert.ErrorIs(t, s.ValidateContext(context.Background(), "bag"), bagit.ErrInvalid)
+		assert.Equal(t, len(fake.Calls()), 1)
+	})
+
+	t.Run("Gives up after the last attempt", func(t *testing.T) {
+		var fake bagittest.Fake
+		fake.OnAny(bagittest.Response{Err: bagit.ErrBusy})
+		s := bagit.Intercept(&fake, bagit.RetryInterceptor(bagit.RetryPolicy{
+			Attempts:  2,
+			Backoff:   time.Millisecond,
+			Retryable: func(err error) bool { return errors.Is(err, bagit.ErrBusy) },
+		}))
+
+		assert.ErrorIs(t, s.ValidateContext(context.Background(), "bag"), bagit.ErrBusy)
+		assert.Equal(t, len(fake.Calls()), 2)
+	})
+
+	t.Run("Stops retrying when the context is done", func(t *testing.T) {
+		var fake bagittest.Fake
+		fake.OnAny(bagittest.Response{Err: bagit.ErrBusy})
+		s := bagit.Intercept(&fake, bagit.RetryInterceptor(bagit.RetryPolicy{Attempts: 10, Backoff: time.Minute}))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, s.ValidateContext(ctx, "bag"), bagit.ErrBusy)
+		assert.Equal(t, len(fake.Calls()), 1)
+	})
+
+	t.Run("Observes commands", func(t *testing.T) {
+		var fake bagittest.Fake
+		fake.OnAny(bagittest.Response{Latency: 5 * time.Millisecond, Err: bagit.ErrBusy})
+
+		var observed []string
+		s := bagit.Intercept(&fake, bagit.MetricsInterceptor(func(ctx context.Context, cmd bagit.Command, d time.Duration, err error) {
+			assert.Assert(t, d >= 5*time.Millisecond)
+			observed = append(observed, cmd.Name+" "+err.Error())
+		}))
+
+		_, err := s.InspectContext(context.Background(), "bag")
+		assert.ErrorIs(t, err, bagit.ErrBusy)
+		assert.DeepEqual(t, observed, []string{"Inspect runner is busy"})
+	})
+}
@@ -0,0 +1,107 @@
+package bagit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const tracerName = "github.com/artefactual-labs/bagit-gython"
+
+// WithTracerProvider records OpenTelemetry spans with tp: the validations of
+// a Validator, the wait for a runner, the extraction of the embedded runtime,
+// the commands sent to the runner processes and their handling inside the
+// runner, including the phases of a validation, e.g. the computation of
+// checksums. The trace context is sent to the runner with every command so
+// that the time spent in bagit-python and its phases become child spans of the
+// command.
+//
+// By default, no spans are recorded. Pass otel.GetTracerProvider() to use the
+// global provider.
+func WithTracerProvider(tp trace.TracerProvider) ValidatorOption {
+	return runnerOption(func(cfg *runnerConfig) {
+		cfg.tracerProvider = tp
+	})
+}
+
+// tracer returns the tracer of the package from the configured provider.
+func (cfg runnerConfig) tracer() trace.Tracer {
+	tp := cfg.tracerProvider
+	if tp == nil {
+		tp = noop.NewTracerProvider()
+	}
+
+	return tp.Tracer(tracerName)
+}
+
+// endSpan records err, if any, and ends span.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// traceContext returns the W3C trace context of the span of ctx, or nil if it
+// is not recording.
+func traceContext(ctx context.Context) map[string]string {
+	if !trace.SpanFromContext(ctx).IsRecording() {
+		return nil
+	}
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+
+	return carrier
+}
+
+// spanPrefix starts the interim lines written by the runner to report the
+// handling of a command and its phases.
+var spanPrefix = []byte(`{"span":`)
+
+// runnerSpan is the handling of a command, or one of its phases, timed by the
+// runner.
+type runnerSpan struct {
+	Name        string `json:"name"`
+	Traceparent string `json:"traceparent"`
+	Start       int64  `json:"start"` // Unix time in nanoseconds.
+	End         int64  `json:"end"`
+	Error       string `json:"error"`
+}
+
+type spanEvent struct {
+	Span runnerSpan `json:"span"`
+}
+
+// readSpan reports whether line is a span event, recording it with tracer as a
+// child of the span described by its trace context.
+func readSpan(line []byte, tracer trace.Tracer) (bool, error) {
+	if !bytes.HasPrefix(line, spanPrefix) {
+		return false, nil
+	}
+
+	var ev spanEvent
+	if err := json.Unmarshal(line, &ev); err != nil {
+		return true, err
+	}
+	s := ev.Span
+
+	ctx := propagation.TraceContext{}.Extract(context.Background(), propagation.MapCarrier{"traceparent": s.Traceparent})
+	_, span := tracer.Start(ctx, "bagit.runner."+s.Name,
+		trace.WithTimestamp(time.Unix(0, s.Start)),
+		trace.WithAttributes(attribute.String("bagit.phase", s.Name)),
+	)
+	if s.Error != "" {
+		span.SetStatus(codes.Error, s.Error)
+	}
+	span.End(trace.WithTimestamp(time.Unix(0, s.End)))
+
+	return true, nil
+}
@@ -0,0 +1,121 @@
+package bagit_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/artefactual-labs/bagit-gython"
+	"github.com/artefactual-labs/bagit-gython/bagittest"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"gotest.tools/v3/assert"
+)
+
+func TestValidatorTracing(t *testing.T) {
+	t.Parallel()
+
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	t.Cleanup(func() {
+		assert.NilError(t, tp.Shutdown(context.Background()))
+	})
+
+	v, err := bagit.NewValidator(
+		bagit.WithTempCacheDir(),
+		bagit.WithDeferredRuntime(),
+		bagit.WithTracerProvider(tp),
+	)
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, v.Close())
+	})
+
+	valid := bagittest.NewBuilder().WithFile("hello.txt", "hello world").BuildTemp(t)
+	invalid := bagittest.NewBuilder().
+		WithFile("hello.txt", "hello world").
+		WithCorruptions(bagittest.FlipByte).
+		BuildTemp(t)
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "test")
+	assert.NilError(t, v.ValidateContext(ctx, valid))
+	parent.End()
+
+	spans := exp.GetSpans()
+	byName := map[string]tracetest.SpanStub{}
+	for _, span := range spans {
+		byName[span.Name] = span
+		assert.Equal(t, span.SpanContext.TraceID(), parent.SpanContext().TraceID(), span.Name)
+	}
+	parentOf := func(name string) string {
+		t.Helper()
+		span, ok := byName[name]
+		assert.Assert(t, ok, "missing span %q", name)
+		for _, s := range spans {
+			if s.SpanContext.SpanID() == span.Parent.SpanID() {
+				return s.Name
+			}
+		}
+		return ""
+	}
+
+	assert.Equal(t, parentOf("bagit.Validate"), "test")
+	assert.Equal(t, parentOf("bagit.acquire"), "bagit.Validate")
+	assert.Equal(t, parentOf("bagit.bootstrap"), "bagit.Validate")
+	assert.Equal(t, parentOf("bagit.runner.validate"), "bagit.Validate")
+	assert.Equal(t, parentOf("bagit.runner.handle"), "bagit.runner.validate")
+	for _, phase := range []string{"structure", "oxum", "completeness", "fixity"} {
+		assert.Equal(t, parentOf("bagit.runner."+phase), "bagit.runner.validate")
+	}
+	assert.Equal(t, byName["bagit.runner.validate"].SpanKind, trace.SpanKindClient)
+	handle := byName["bagit.runner.handle"]
+	assert.Assert(t, !handle.StartTime.After(handle.EndTime))
+	assert.Assert(t, !handle.StartTime.Before(byName["bagit.runner.validate"].StartTime))
+	fixity := byName["bagit.runner.fixity"]
+	assert.Assert(t, !fixity.StartTime.After(fixity.EndTime))
+	assert.Assert(t, !fixity.StartTime.Before(handle.StartTime))
+
+	exp.Reset()
+	assert.ErrorIs(t, v.TryValidate(invalid), bagit.ErrInvalid)
+
+	byName = map[string]tracetest.SpanStub{}
+	for _, span := range exp.GetSpans() {
+		byName[span.Name] = span
+	}
+	_, ok := byName["bagit.bootstrap"]
+	assert.Assert(t, !ok)
+	assert.Equal(t, byName["bagit.Validate"].Status.Code, codes.Error)
+	assert.Equal(t, byName["bagit.runner.handle"].Status.Code, codes.Error)
+	assert.Equal(t, byName["bagit.runner.fixity"].Status.Code, codes.Error)
+	for _, attr := range byName["bagit.Validate"].Attributes {
+		if attr.Key == "bagit.outcome" {
+			assert.Equal(t, attr.Value.AsString(), string(bagit.OutcomeInvalid))
+		}
+	}
+}
+
+func TestValidatorTracingDisabled(t *testing.T) {
+	t.Parallel()
+
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	t.Cleanup(func() {
+		assert.NilError(t, tp.Shutdown(context.Background()))
+	})
+
+	v, err := bagit.NewValidator(bagit.WithTempCacheDir(), bagit.WithDeferredRuntime())
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, v.Close())
+	})
+
+	// A recording span of another provider does not make the runner report
+	// its spans or phases, since the Validator records nothing.
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "test")
+	path := bagittest.NewBuilder().WithFile("hello.txt", "hello world").BuildTemp(t)
+	assert.NilError(t, v.ValidateContext(ctx, path))
+	parent.End()
+
+	assert.Equal(t, len(exp.GetSpans()), 1)
+}
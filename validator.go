@@ -6,8 +6,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
+	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/semaphore"
 )
 
@@ -16,22 +20,56 @@ const (
 	defaultValidatorCacheDirName = "bagit-gython"
 )
 
-// ValidatorOption configures a Validator.
+// ValidatorOption configures a Validator. Options configuring its runners,
+// e.g. WithLogger, also configure a BagIt, see NewBagIt.
 type ValidatorOption func(*validatorConfig)
 
 type validatorConfig struct {
+	minRunners      int
 	poolSize        int
+	idleTimeout     time.Duration
+	warmup          bool
+	healthInterval  time.Duration
+	healthTimeout   time.Duration
+	maxRequests     int
+	maxRSS          int64
 	cacheDir        string
 	deferredRuntime bool
+	backend         Backend
+	metrics         Metrics
+	runner          runnerConfig
 }
 
 // WithPoolSize sets the number of BagIt runners owned by a Validator.
 //
 // A larger pool allows more validations to run in parallel, at the cost of
-// creating more embedded Python runner processes.
+// creating more embedded Python runner processes. It is equivalent to
+// WithPoolBounds(size, size).
 func WithPoolSize(size int) ValidatorOption {
+	return WithPoolBounds(size, size)
+}
+
+// WithPoolBounds lets the number of BagIt runners owned by a Validator vary
+// between minRunners and maxRunners.
+//
+// The pool starts with minRunners runners and creates more on demand, up to
+// maxRunners, when all of them are busy. At most maxRunners commands run at the
+// same time; additional callers wait as with WithPoolSize. Use WithIdleTimeout
+// to stop the runners added on demand once the load drops.
+func WithPoolBounds(minRunners, maxRunners int) ValidatorOption {
+	return func(cfg *validatorConfig) {
+		cfg.minRunners = minRunners
+		cfg.poolSize = maxRunners
+	}
+}
+
+// WithIdleTimeout stops the runners that have been idle for at least d, along
+// with their Python processes, as long as the pool keeps the minimum set by
+// WithPoolBounds. Idle runners are checked every d/2. By default, runners are
+// never stopped before Close.
+func WithIdleTimeout(d time.Duration) ValidatorOption {
 	return func(cfg *validatorConfig) {
-		cfg.poolSize = size
+		cfg.idleTimeout = d
 	}
 }
 
@@ -73,23 +111,98 @@ func WithDeferredRuntime() ValidatorOption {
 	}
 }
 
-// Validator is a bounded pool of BagIt validators sharing one embedded runtime.
+// WithMaxRestarts caps the number of consecutive restarts of a crashed runner.
+//
+// Runners that crash, e.g. because the Python process is killed or exits
+// unexpectedly, are restarted by the next command after a short backoff. Once
+// a runner has crashed more than n times in a row without completing a
+// command, its commands fail immediately with a *RunnerError instead of
+// restarting it again. The default, zero, allows unlimited restarts.
+func WithMaxRestarts(n int) ValidatorOption {
+	return runnerOption(func(cfg *runnerConfig) {
+		cfg.maxRestarts = n
+	})
+}
+
+// Backend is the engine used by Validator to validate bags, see WithBackend.
+type Backend int
+
+const (
+	// BackendPython validates bags with bagit-python in the embedded runners.
+	// It is the default and the reference implementation.
+	BackendPython Backend = iota
+
+	// BackendNative validates bags in Go, without starting the embedded
+	// runtime. It implements the structure, Payload-Oxum, completeness and
+	// fixity checks of bagit-python with the same results and error types.
+	//
+	// Tag files may use any encoding of the IANA registry. Validating the
+	// checksums of manifests using the blake2 or shake algorithms returns an
+	// error wrapping errors.ErrUnsupported.
+	BackendNative
+)
+
+func (b Backend) String() string {
+	switch b {
+	case BackendPython:
+		return "python"
+	case BackendNative:
+		return "native"
+	default:
+		return fmt.Sprintf("Backend(%d)", int(b))
+	}
+}
+
+// WithBackend sets the engine used by Validate, ValidateContext and
+// TryValidate, BackendPython by default.
+//
+// With BackendNative, the embedded runtime is only extracted and started when
+// a command without a native implementation is used, e.g. Make or Inspect, so
+// validators that only validate never pay for it. Validations still count
+// against the pool size, which bounds how many run at the same time.
+func WithBackend(b Backend) ValidatorOption {
+	return func(cfg *validatorConfig) {
+		cfg.backend = b
+	}
+}
+
+// Validator is a bounded pool of BagIt runners sharing one embedded runtime.
 //
-// It is safe for concurrent use. At most pool size validations are executed at
-// the same time; additional callers wait for a runner to become available
-// instead of creating new temporary Python extractions.
+// It is safe for concurrent use. At most pool size commands, e.g. validations
+// or Make calls, are executed at the same time; additional callers wait for a
+// runner to become available instead of creating new temporary Python
+// extractions.
 type Validator struct {
-	poolSize   int64
-	sem        *semaphore.Weighted
-	runtimeCfg bagItRuntimeConfig
+	poolSize       int64
+	minRunners     int
+	idleTimeout    time.Duration
+	warmup         bool
+	healthInterval time.Duration
+	healthTimeout  time.Duration
+	maxRequests    int
+	maxRSS         int64
+	sem            *semaphore.Weighted
+	runtimeCfg     bagItRuntimeConfig
+	runnerCfg      runnerConfig
+	backend        Backend
+	metrics        Metrics
+	tracer         trace.Tracer
+
+	busyMu sync.Mutex // Serializes the reports of busy runners.
+	busy   int
 
 	mu      sync.Mutex
 	pool    []*BagIt
-	idle    []*BagIt
+	idle    []idleRunner // Oldest first.
+	runners int          // Runners created, used to index their logs.
 	closed  bool
 	runtime *bagItRuntime
 
-	bootstrapOnce sync.Once
+	done       chan struct{}  // Closed by Close to stop background tasks.
+	background sync.WaitGroup // Tracks the idle reaper, health checks and retired runners.
+
+	bootstrapMu   sync.Mutex // Serializes bootstraps until one is done.
+	bootstrapDone bool
 	bootstrapErr  error
 	closeOnce     sync.Once
 	closeErr      error
@@ -106,8 +219,9 @@ type Validator struct {
 // validation request.
 func NewValidator(opts ...ValidatorOption) (*Validator, error) {
 	cfg := validatorConfig{
-		poolSize: defaultValidatorPoolSize,
-		cacheDir: defaultValidatorCacheDir(),
+		minRunners: defaultValidatorPoolSize,
+		poolSize:   defaultValidatorPoolSize,
+		cacheDir:   defaultValidatorCacheDir(),
 	}
 	for _, opt := range opts {
 		opt(&cfg)
@@ -115,15 +229,61 @@ func NewValidator(opts ...ValidatorOption) (*Validator, error) {
 	if cfg.poolSize < 1 {
 		return nil, fmt.Errorf("pool size must be greater than zero")
 	}
+	if cfg.minRunners < 0 {
+		return nil, fmt.Errorf("min runners must not be negative")
+	}
+	if cfg.minRunners > cfg.poolSize {
+		return nil, fmt.Errorf("min runners must not exceed max runners")
+	}
+	if cfg.idleTimeout < 0 {
+		return nil, fmt.Errorf("idle timeout must not be negative")
+	}
+	if cfg.healthInterval < 0 {
+		return nil, fmt.Errorf("health check interval must not be negative")
+	}
+	if cfg.healthInterval > 0 && cfg.healthTimeout <= 0 {
+		return nil, fmt.Errorf("health check timeout must be greater than zero")
+	}
+	if cfg.maxRequests < 0 {
+		return nil, fmt.Errorf("max requests per runner must not be negative")
+	}
+	if cfg.maxRSS < 0 {
+		return nil, fmt.Errorf("max runner RSS must not be negative")
+	}
+	if cfg.backend != BackendPython && cfg.backend != BackendNative {
+		return nil, fmt.Errorf("unknown backend: %v", cfg.backend)
+	}
+	if err := cfg.runner.validate(); err != nil {
+		return nil, err
+	}
+
+	metrics := cfg.metrics
+	if metrics == nil {
+		metrics = nopMetrics{}
+	}
+	cfg.runner.onRestart = metrics.RunnerRestarted
+	metrics.PoolSize(cfg.poolSize)
 
 	v := &Validator{
-		poolSize:   int64(cfg.poolSize),
-		sem:        semaphore.NewWeighted(int64(cfg.poolSize)),
-		runtimeCfg: bagItRuntimeConfig{cacheDir: cfg.cacheDir},
+		poolSize:       int64(cfg.poolSize),
+		minRunners:     cfg.minRunners,
+		idleTimeout:    cfg.idleTimeout,
+		warmup:         cfg.warmup,
+		healthInterval: cfg.healthInterval,
+		healthTimeout:  cfg.healthTimeout,
+		maxRequests:    cfg.maxRequests,
+		maxRSS:         cfg.maxRSS,
+		sem:            semaphore.NewWeighted(int64(cfg.poolSize)),
+		runtimeCfg:     bagItRuntimeConfig{cacheDir: cfg.cacheDir},
+		runnerCfg:      cfg.runner,
+		backend:        cfg.backend,
+		metrics:        metrics,
+		tracer:         cfg.runner.tracer(),
+		done:           make(chan struct{}),
 	}
 
-	if !cfg.deferredRuntime {
-		if err := v.ensureBootstrapped(); err != nil {
+	if !cfg.deferredRuntime && cfg.backend == BackendPython {
+		if err := v.ensureBootstrapped(context.Background()); err != nil {
 			return nil, err
 		}
 	}
@@ -131,7 +291,9 @@ func NewValidator(opts ...ValidatorOption) (*Validator, error) {
 	return v, nil
 }
 
-// PoolSize returns the number of BagIt runners owned by v.
+// PoolSize returns the maximum number of BagIt runners owned by v, i.e. the
+// number of commands it runs at the same time. See Stats for the number of
+// runners in the pool.
 func (v *Validator) PoolSize() int {
 	if v == nil {
 		return 0
@@ -139,53 +301,377 @@ func (v *Validator) PoolSize() int {
 	return int(v.poolSize)
 }
 
+// PoolStats describes the runners of a Validator, see Validator.Stats.
+type PoolStats struct {
+	MinRunners int // Runners kept when idle, see WithPoolBounds.
+	MaxRunners int // Maximum number of runners, see PoolSize.
+	Runners    int // Runners in the pool.
+	Busy       int // Runners executing a command.
+	Idle       int // Runners waiting for a command.
+}
+
+// Stats reports the current size of the pool of v against its bounds.
+//
+// With BackendNative, the pool has no runners and Busy is the number of
+// validations in progress.
+func (v *Validator) Stats() PoolStats {
+	if v == nil {
+		return PoolStats{}
+	}
+
+	stats := PoolStats{MinRunners: v.minRunners, MaxRunners: int(v.poolSize)}
+	if v.backend == BackendNative {
+		v.busyMu.Lock()
+		stats.Busy = v.busy
+		v.busyMu.Unlock()
+		return stats
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	stats.Runners = len(v.pool)
+	stats.Idle = len(v.idle)
+	stats.Busy = stats.Runners - stats.Idle
+
+	return stats
+}
+
 // Validate validates path with a pooled BagIt runner.
 //
 // Validate blocks while all runners are busy. Use ValidateContext when the wait
-// should respect cancellation or deadlines.
-func (v *Validator) Validate(path string) error {
-	return v.ValidateContext(context.Background(), path)
+// should respect cancellation or deadlines. Options apply to this validation
+// only, e.g. WithFast or WithProcesses.
+func (v *Validator) Validate(path string, opts ...ValidateOption) error {
+	return v.ValidateContext(context.Background(), path, opts...)
 }
 
 // ValidateContext validates path with a pooled BagIt runner.
 //
-// The context controls waiting for an available runner. Once a runner has been
-// acquired, the validation runs to completion.
-func (v *Validator) ValidateContext(ctx context.Context, path string) error {
+// The context controls waiting for an available runner and the validation
+// itself: if ctx is done while the bag is being validated, the runner process
+// is terminated and replaced, and ValidateContext returns ctx.Err(). Invalid
+// bags are reported with a *ValidationError, see BagIt.Validate.
+//
+// A serialized bag is extracted before waiting for a runner, so that its
+// extraction does not hold one.
+func (v *Validator) ValidateContext(ctx context.Context, path string, opts ...ValidateOption) error {
+	if v == nil {
+		return ErrClosed
+	}
+	opts = v.validateOptions(opts)
+
+	return v.observeValidation(ctx, path, func(ctx context.Context) error {
+		// Serialized bags are extracted before waiting for a runner, which
+		// is only held for the validation itself.
+		bag, err := extractPath(ctx, path, v.runnerCfg.extractLimits)
+		if err != nil {
+			return err
+		}
+		defer bag.cleanup()
+
+		if v.backend == BackendNative {
+			return v.runNative(ctx, func(ctx context.Context) error {
+				return validateNative(ctx, path, bag.path, v.runnerCfg.requestTimeout, opts...)
+			})
+		}
+
+		return v.run(ctx, func(b *BagIt) error {
+			return b.validate(ctx, path, bag.path, opts...)
+		})
+	})
+}
+
+// TryValidate validates path with a pooled BagIt runner if one is immediately
+// available.
+//
+// TryValidate returns ErrBusy instead of waiting when all runners are busy. A
+// serialized bag is extracted first, see ValidateContext, and removed again if
+// no runner is available.
+func (v *Validator) TryValidate(path string, opts ...ValidateOption) error {
+	if v == nil {
+		return ErrClosed
+	}
+	opts = v.validateOptions(opts)
+
+	return v.observeValidation(context.Background(), path, func(ctx context.Context) error {
+		bag, err := extractPath(ctx, path, v.runnerCfg.extractLimits)
+		if err != nil {
+			return err
+		}
+		defer bag.cleanup()
+
+		if v.backend == BackendNative {
+			return v.tryRunNative(ctx, func(ctx context.Context) error {
+				return validateNative(ctx, path, bag.path, v.runnerCfg.requestTimeout, opts...)
+			})
+		}
+
+		return v.tryRun(ctx, func(b *BagIt) error {
+			return b.validate(ctx, path, bag.path, opts...)
+		})
+	})
+}
+
+// Make converts the directory at path into a bag with a pooled BagIt runner.
+//
+// Make blocks while all runners are busy. Use MakeContext when the wait should
+// respect cancellation or deadlines.
+func (v *Validator) Make(path string, opts MakeOptions) (MakeResult, error) {
+	return v.MakeContext(context.Background(), path, opts)
+}
+
+// MakeContext converts the directory at path into a bag with a pooled BagIt
+// runner, see BagIt.Make.
+//
+// The context controls waiting for an available runner and the command itself,
+// see ValidateContext.
+func (v *Validator) MakeContext(ctx context.Context, path string, opts MakeOptions) (res MakeResult, err error) {
+	err = v.run(ctx, func(b *BagIt) error {
+		res, err = b.MakeContext(ctx, path, opts)
+		return err
+	})
+
+	return res, err
+}
+
+// TryMake converts the directory at path into a bag with a pooled BagIt runner
+// if one is immediately available.
+//
+// TryMake returns ErrBusy instead of waiting when all runners are busy.
+func (v *Validator) TryMake(path string, opts MakeOptions) (res MakeResult, err error) {
+	err = v.tryRun(context.Background(), func(b *BagIt) error {
+		res, err = b.Make(path, opts)
+		return err
+	})
+
+	return res, err
+}
+
+// Update changes the bag at path in place with a pooled BagIt runner, see
+// BagIt.Update.
+//
+// Update blocks while all runners are busy. Use UpdateContext when the wait
+// should respect cancellation or deadlines.
+func (v *Validator) Update(path string, opts UpdateOptions) error {
+	return v.UpdateContext(context.Background(), path, opts)
+}
+
+// UpdateContext changes the bag at path in place with a pooled BagIt runner.
+//
+// The context controls waiting for an available runner and the command itself,
+// see ValidateContext.
+func (v *Validator) UpdateContext(ctx context.Context, path string, opts UpdateOptions) error {
+	return v.run(ctx, func(b *BagIt) error {
+		return b.UpdateContext(ctx, path, opts)
+	})
+}
+
+// TryUpdate changes the bag at path in place with a pooled BagIt runner if one
+// is immediately available.
+//
+// TryUpdate returns ErrBusy instead of waiting when all runners are busy.
+func (v *Validator) TryUpdate(path string, opts UpdateOptions) error {
+	return v.tryRun(context.Background(), func(b *BagIt) error {
+		return b.Update(path, opts)
+	})
+}
+
+// Inspect reads the bag at path with a pooled BagIt runner, see
+// BagIt.Inspect.
+//
+// Inspect blocks while all runners are busy. Use InspectContext when the wait
+// should respect cancellation or deadlines.
+func (v *Validator) Inspect(path string) (*Bag, error) {
+	return v.InspectContext(context.Background(), path)
+}
+
+// InspectContext reads the bag at path with a pooled BagIt runner.
+//
+// The context controls waiting for an available runner and the command itself,
+// see ValidateContext.
+func (v *Validator) InspectContext(ctx context.Context, path string) (bag *Bag, err error) {
+	err = v.run(ctx, func(b *BagIt) error {
+		bag, err = b.InspectContext(ctx, path)
+		return err
+	})
+
+	return bag, err
+}
+
+// TryInspect reads the bag at path with a pooled BagIt runner if one is
+// immediately available.
+//
+// TryInspect returns ErrBusy instead of waiting when all runners are busy.
+func (v *Validator) TryInspect(path string) (bag *Bag, err error) {
+	err = v.tryRun(context.Background(), func(b *BagIt) error {
+		bag, err = b.Inspect(path)
+		return err
+	})
+
+	return bag, err
+}
+
+// ValidateProfile checks the bag at path against profile with a pooled BagIt
+// runner, see BagIt.ValidateProfile.
+//
+// ValidateProfile blocks while all runners are busy. Use
+// ValidateProfileContext when the wait should respect cancellation or
+// deadlines.
+func (v *Validator) ValidateProfile(path string, profile *Profile) error {
+	return v.ValidateProfileContext(context.Background(), path, profile)
+}
+
+// ValidateProfileContext checks the bag at path against profile with a pooled
+// BagIt runner.
+//
+// The context controls waiting for an available runner and the command itself,
+// see ValidateContext. Like there, a serialized bag is extracted before waiting
+// for a runner.
+func (v *Validator) ValidateProfileContext(ctx context.Context, path string, profile *Profile) error {
 	if v == nil {
 		return ErrClosed
 	}
+	if profile == nil {
+		return errors.New("validate profile: profile is nil")
+	}
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
-	if err := v.acquire(ctx); err != nil {
+	// Serialized bags are extracted before waiting for a runner, see
+	// ValidateContext.
+	bag, err := extractPath(ctx, path, v.runnerCfg.extractLimits)
+	if err != nil {
 		return err
 	}
+	defer bag.cleanup()
 
-	if err := v.ensureBootstrapped(); err != nil {
-		v.sem.Release(1)
+	return v.run(ctx, func(b *BagIt) error {
+		return b.validateProfile(ctx, bag, profile)
+	})
+}
+
+// TryValidateProfile checks the bag at path against profile with a pooled
+// BagIt runner if one is immediately available.
+//
+// TryValidateProfile returns ErrBusy instead of waiting when all runners are
+// busy.
+func (v *Validator) TryValidateProfile(path string, profile *Profile) error {
+	if v == nil {
+		return ErrClosed
+	}
+	if profile == nil {
+		return errors.New("validate profile: profile is nil")
+	}
+
+	bag, err := extractPath(context.Background(), path, v.runnerCfg.extractLimits)
+	if err != nil {
 		return err
 	}
+	defer bag.cleanup()
 
-	b, err := v.take()
+	return v.tryRun(context.Background(), func(b *BagIt) error {
+		return b.validateProfile(context.Background(), bag, profile)
+	})
+}
+
+// Complete downloads the missing files of the holey bag at path, then
+// validates it, see BagIt.Complete.
+//
+// Complete blocks while all runners are busy. Use CompleteContext when the
+// wait should respect cancellation or deadlines.
+func (v *Validator) Complete(path string, fetcher Fetcher, opts CompleteOptions) error {
+	return v.CompleteContext(context.Background(), path, fetcher, opts)
+}
+
+// CompleteContext downloads the missing files of the holey bag at path, then
+// validates it.
+//
+// A pooled BagIt runner is only used to read fetch.txt and to validate the
+// bag, so the downloads do not hold a runner. The context controls waiting for
+// an available runner, the downloads and the validation, see
+// BagIt.CompleteContext.
+func (v *Validator) CompleteContext(ctx context.Context, path string, fetcher Fetcher, opts CompleteOptions) error {
+	bag, err := v.InspectContext(ctx, path)
 	if err != nil {
-		v.sem.Release(1)
 		return err
 	}
-	defer func() {
-		v.put(b)
-		v.sem.Release(1)
-	}()
+	if err := fetchAll(ctx, fetcher, path, bag.Fetch, opts); err != nil {
+		return err
+	}
 
-	return b.Validate(path)
+	return v.ValidateContext(ctx, path, WithProcesses(opts.Processes))
 }
 
-// TryValidate validates path with a pooled BagIt runner if one is immediately
-// available.
+// TryComplete downloads the missing files of the holey bag at path, then
+// validates it, if a pooled BagIt runner is immediately available for each
+// step, see CompleteContext.
 //
-// TryValidate returns ErrBusy instead of waiting when all runners are busy.
-func (v *Validator) TryValidate(path string) error {
+// TryComplete returns ErrBusy instead of waiting when all runners are busy. If
+// they are busy once the downloads are done, the downloaded files are kept and
+// calling TryComplete again only validates the bag.
+func (v *Validator) TryComplete(path string, fetcher Fetcher, opts CompleteOptions) error {
+	bag, err := v.TryInspect(path)
+	if err != nil {
+		return err
+	}
+	if err := fetchAll(context.Background(), fetcher, path, bag.Fetch, opts); err != nil {
+		return err
+	}
+
+	return v.TryValidate(path, WithProcesses(opts.Processes))
+}
+
+// validateOptions adds the options measuring a validation to opts.
+func (v *Validator) validateOptions(opts []ValidateOption) []ValidateOption {
+	if _, ok := v.metrics.(nopMetrics); ok {
+		return opts
+	}
+
+	return append(slices.Clone(opts), withBytesHashed(v.metrics.BytesHashed))
+}
+
+// observeValidation calls fn within the span of the validation of path,
+// reporting its outcome and duration.
+func (v *Validator) observeValidation(ctx context.Context, path string, fn func(context.Context) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, span := v.tracer.Start(ctx, "bagit.Validate", trace.WithAttributes(
+		attribute.String("bagit.path", path),
+		attribute.String("bagit.backend", v.backend.String()),
+	))
+
+	start := time.Now()
+	err := fn(ctx)
+	outcome := validationOutcome(err)
+	v.metrics.Validation(outcome, time.Since(start))
+
+	span.SetAttributes(attribute.String("bagit.outcome", string(outcome)))
+	endSpan(span, err)
+
+	return err
+}
+
+// run waits for an available runner, then calls fn with it.
+func (v *Validator) run(ctx context.Context, fn func(*BagIt) error) error {
+	if v == nil {
+		return ErrClosed
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := v.acquire(ctx); err != nil {
+		return err
+	}
+
+	return v.runAcquired(ctx, fn)
+}
+
+// tryRun calls fn with an available runner, or returns ErrBusy if there is
+// none.
+func (v *Validator) tryRun(ctx context.Context, fn func(*BagIt) error) error {
 	if v == nil {
 		return ErrClosed
 	}
@@ -194,28 +680,67 @@ func (v *Validator) TryValidate(path string) error {
 		return err
 	}
 
-	if err := v.ensureBootstrapped(); err != nil {
-		v.sem.Release(1)
+	return v.runAcquired(ctx, fn)
+}
+
+// runNative waits for an available pool slot, then calls fn without a
+// runner, see BackendNative.
+func (v *Validator) runNative(ctx context.Context, fn func(context.Context) error) error {
+	if v == nil {
+		return ErrClosed
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := v.acquire(ctx); err != nil {
+		return err
+	}
+	defer v.release()
+
+	return fn(ctx)
+}
+
+// tryRunNative calls fn without a runner if a pool slot is available, or
+// returns ErrBusy if there is none.
+func (v *Validator) tryRunNative(ctx context.Context, fn func(context.Context) error) error {
+	if v == nil {
+		return ErrClosed
+	}
+
+	if err := v.tryAcquire(); err != nil {
+		return err
+	}
+	defer v.release()
+
+	return fn(ctx)
+}
+
+// runAcquired calls fn with a pooled runner. The caller must hold a semaphore
+// slot, which runAcquired releases.
+func (v *Validator) runAcquired(ctx context.Context, fn func(*BagIt) error) error {
+	if err := v.ensureBootstrapped(ctx); err != nil {
+		v.release()
 		return err
 	}
 
 	b, err := v.take()
 	if err != nil {
-		v.sem.Release(1)
+		v.release()
 		return err
 	}
 	defer func() {
-		v.put(b)
-		v.sem.Release(1)
+		v.put(v.retire(b))
+		v.release()
 	}()
 
-	return b.Validate(path)
+	return fn(b)
 }
 
 // Close releases all embedded Python resources owned by v.
 //
-// Close waits for active validations to finish before cleaning up. Calls to
-// Validate made after Close starts return ErrClosed.
+// Close waits for active commands to finish before cleaning up. Calls made
+// after Close starts return ErrClosed.
 func (v *Validator) Close() error {
 	if v == nil {
 		return nil
@@ -241,7 +766,12 @@ func (v *Validator) acquire(ctx context.Context) error {
 		return ErrClosed
 	}
 
-	if err := v.sem.Acquire(ctx, 1); err != nil {
+	_, span := v.tracer.Start(ctx, "bagit.acquire")
+	start := time.Now()
+	err := v.sem.Acquire(ctx, 1)
+	v.metrics.AcquireWait(time.Since(start))
+	endSpan(span, err)
+	if err != nil {
 		return err
 	}
 
@@ -252,6 +782,7 @@ func (v *Validator) acquire(ctx context.Context) error {
 		v.sem.Release(1)
 		return ErrClosed
 	}
+	v.addBusy(1)
 
 	return nil
 }
@@ -275,54 +806,122 @@ func (v *Validator) tryAcquire() error {
 		v.sem.Release(1)
 		return ErrClosed
 	}
+	v.addBusy(1)
 
 	return nil
 }
 
-func (v *Validator) ensureBootstrapped() error {
-	v.bootstrapOnce.Do(func() {
-		v.bootstrapErr = v.bootstrap()
-	})
+// release releases a semaphore slot taken by acquire or tryAcquire.
+func (v *Validator) release() {
+	v.addBusy(-1)
+	v.sem.Release(1)
+}
+
+func (v *Validator) addBusy(delta int) {
+	v.busyMu.Lock()
+	defer v.busyMu.Unlock()
+
+	v.busy += delta
+	v.metrics.RunnersBusy(v.busy)
+}
+
+func (v *Validator) ensureBootstrapped(ctx context.Context) error {
+	v.bootstrapMu.Lock()
+	defer v.bootstrapMu.Unlock()
+
+	if v.bootstrapDone {
+		return v.bootstrapErr
+	}
 
-	return v.bootstrapErr
+	err := v.bootstrap(ctx)
+	// A bootstrap interrupted by ctx, e.g. during the warm-up, is tried again
+	// by the next command instead of failing the Validator for good.
+	if err == nil || ctx.Err() == nil {
+		v.bootstrapDone = true
+		v.bootstrapErr = err
+	}
+
+	return err
 }
 
-func (v *Validator) bootstrap() error {
+func (v *Validator) bootstrap(ctx context.Context) error {
+	_, span := v.tracer.Start(ctx, "bagit.bootstrap")
+	start := time.Now()
 	runtime, err := newBagItRuntime(v.runtimeCfg)
+	endSpan(span, err)
 	if err != nil {
 		return err
 	}
+	v.metrics.Bootstrap(time.Since(start))
 
-	poolSize := int(v.poolSize)
-	pool := make([]*BagIt, 0, poolSize)
-	idle := make([]*BagIt, 0, poolSize)
-	for i := 0; i < poolSize; i++ {
-		b := newBagIt(runtime, false)
+	now := time.Now()
+	pool := make([]*BagIt, 0, v.poolSize)
+	idle := make([]idleRunner, 0, v.poolSize)
+	for i := 0; i < v.minRunners; i++ {
+		b := newBagIt(runtime, false, v.runnerCfg.withIndex(i))
 		pool = append(pool, b)
-		idle = append(idle, b)
+		idle = append(idle, idleRunner{b: b, since: now})
+	}
+	if v.warmup {
+		if err := warmUp(ctx, pool); err != nil {
+			for _, b := range pool {
+				if cleanupErr := b.Cleanup(); cleanupErr != nil {
+					err = errors.Join(err, fmt.Errorf("clean up failed warm-up: %v", cleanupErr))
+				}
+			}
+			if cleanupErr := runtime.cleanup(); cleanupErr != nil {
+				err = errors.Join(err, fmt.Errorf("clean up failed warm-up: %v", cleanupErr))
+			}
+			return err
+		}
 	}
 
 	v.mu.Lock()
 	v.runtime = runtime
 	v.pool = pool
 	v.idle = idle
+	v.runners = len(pool)
+	v.metrics.Runners(len(pool))
 	v.mu.Unlock()
 
+	if v.idleTimeout > 0 && int64(v.minRunners) < v.poolSize {
+		v.background.Add(1)
+		go v.reap()
+	}
+	if v.healthInterval > 0 {
+		v.background.Add(1)
+		go v.checkHealth()
+	}
+
 	return nil
 }
 
+// idleRunner is a runner waiting for a command since a given time.
+type idleRunner struct {
+	b     *BagIt
+	since time.Time
+}
+
 func (v *Validator) take() (*BagIt, error) {
 	v.mu.Lock()
 	defer v.mu.Unlock()
 
-	if len(v.idle) == 0 {
-		return nil, fmt.Errorf("validator runner pool is empty")
+	// Take the runner used last, so that the others keep idling and can be
+	// stopped.
+	if i := len(v.idle) - 1; i >= 0 {
+		b := v.idle[i].b
+		v.idle[i] = idleRunner{}
+		v.idle = v.idle[:i]
+		return b, nil
 	}
 
-	i := len(v.idle) - 1
-	b := v.idle[i]
-	v.idle[i] = nil
-	v.idle = v.idle[:i]
+	if v.runtime == nil || int64(len(v.pool)) >= v.poolSize {
+		return nil, fmt.Errorf("validator runner pool is empty")
+	}
+	b := newBagIt(v.runtime, false, v.runnerCfg.withIndex(v.runners))
+	v.runners++
+	v.pool = append(v.pool, b)
+	v.metrics.Runners(len(v.pool))
 
 	return b, nil
 }
@@ -331,7 +930,51 @@ func (v *Validator) put(b *BagIt) {
 	v.mu.Lock()
 	defer v.mu.Unlock()
 
-	v.idle = append(v.idle, b)
+	v.idle = append(v.idle, idleRunner{b: b, since: time.Now()})
+}
+
+// reap stops the runners idling for longer than the idle timeout until Close.
+func (v *Validator) reap() {
+	defer v.background.Done()
+
+	ticker := time.NewTicker(v.idleTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-v.done:
+			return
+		case now := <-ticker.C:
+			v.reapIdle(now)
+		}
+	}
+}
+
+// reapIdle stops the runners idling since before now minus the idle timeout,
+// keeping at least the minimum number of runners.
+func (v *Validator) reapIdle(now time.Time) {
+	var reaped []*BagIt
+
+	v.mu.Lock()
+	for len(v.pool) > v.minRunners && len(v.idle) > 0 && now.Sub(v.idle[0].since) >= v.idleTimeout {
+		b := v.idle[0].b
+		v.idle = slices.Delete(v.idle, 0, 1)
+		v.pool = slices.DeleteFunc(v.pool, func(p *BagIt) bool { return p == b })
+		reaped = append(reaped, b)
+	}
+	if len(reaped) > 0 {
+		v.metrics.Runners(len(v.pool))
+	}
+	v.mu.Unlock()
+
+	for _, b := range reaped {
+		logger := b.runner.logger
+		if err := b.Cleanup(); err != nil {
+			logger.Warn("stop idle runner", "err", err)
+			continue
+		}
+		logger.Debug("idle runner stopped")
+	}
 }
 
 func (v *Validator) close() error {
@@ -348,6 +991,9 @@ func (v *Validator) close() error {
 	}
 	defer v.sem.Release(v.poolSize)
 
+	close(v.done)
+	v.background.Wait()
+
 	return v.cleanup()
 }
 
@@ -358,6 +1004,9 @@ func (v *Validator) cleanup() error {
 	v.pool = nil
 	v.idle = nil
 	v.runtime = nil
+	if pool != nil {
+		v.metrics.Runners(0)
+	}
 	v.mu.Unlock()
 
 	var e error
@@ -1,15 +1,18 @@
 package bagit
 
 import (
+	"archive/tar"
 	"context"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 	"gotest.tools/v3/assert"
+	"gotest.tools/v3/poll"
 )
 
 func TestValidatorSharesRuntimeRootAcrossPool(t *testing.T) {
@@ -224,6 +227,42 @@ func TestValidatorValidateContextHonorsWaitCancellation(t *testing.T) {
 	assert.ErrorIs(t, err, context.Canceled)
 }
 
+func TestValidatorExtractsSerializedBagsBeforeWaiting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bag.tar")
+	f, err := os.Create(path)
+	assert.NilError(t, err)
+	tw := tar.NewWriter(f)
+	assert.NilError(t, tw.WriteHeader(&tar.Header{Name: "bagit.txt", Mode: 0o644}))
+	assert.NilError(t, tw.Close())
+	assert.NilError(t, f.Close())
+
+	for _, backend := range []Backend{BackendPython, BackendNative} {
+		t.Run(backend.String(), func(t *testing.T) {
+			v, err := NewValidator(WithPoolSize(1), WithTempCacheDir(), WithBackend(backend))
+			assert.NilError(t, err)
+			t.Cleanup(func() {
+				assert.NilError(t, v.Close())
+			})
+
+			// The archive is rejected while extracting it, without waiting for
+			// the only pool slot.
+			assert.NilError(t, v.sem.Acquire(context.Background(), 1))
+			defer v.sem.Release(1)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			err = v.ValidateContext(ctx, path)
+			assert.ErrorIs(t, err, ErrInvalid)
+			assert.ErrorContains(t, err, "expected a single top-level directory")
+
+			err = v.ValidateProfileContext(ctx, path, &Profile{})
+			assert.ErrorIs(t, err, ErrInvalid)
+			assert.ErrorContains(t, err, "expected a single top-level directory")
+		})
+	}
+}
+
 func TestValidatorTryValidateReturnsErrBusyWhenPoolBusy(t *testing.T) {
 	v, err := NewValidator(WithPoolSize(1), WithTempCacheDir())
 	assert.NilError(t, err)
@@ -236,6 +275,97 @@ func TestValidatorTryValidateReturnsErrBusyWhenPoolBusy(t *testing.T) {
 
 	err = v.TryValidate("internal/testdata/valid-bag")
 	assert.ErrorIs(t, err, ErrBusy)
+
+	_, err = v.TryMake(t.TempDir(), MakeOptions{})
+	assert.ErrorIs(t, err, ErrBusy)
+}
+
+func TestValidatorMakeContextHonorsWaitCancellation(t *testing.T) {
+	v, err := NewValidator(WithPoolSize(1), WithTempCacheDir())
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, v.Close())
+	})
+
+	assert.NilError(t, v.sem.Acquire(context.Background(), 1))
+	defer v.sem.Release(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = v.MakeContext(ctx, t.TempDir(), MakeOptions{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestValidatorGrowsAndShrinksWithinPoolBounds(t *testing.T) {
+	v, err := NewValidator(WithPoolBounds(1, 3), WithIdleTimeout(time.Hour), WithTempCacheDir())
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, v.Close())
+	})
+
+	assert.Equal(t, v.PoolSize(), 3)
+	assert.DeepEqual(t, v.Stats(), PoolStats{MinRunners: 1, MaxRunners: 3, Runners: 1, Idle: 1})
+
+	// Hold three runners, as concurrent validations would.
+	var taken []*BagIt
+	for range 3 {
+		assert.NilError(t, v.acquire(context.Background()))
+		b, err := v.take()
+		assert.NilError(t, err)
+		taken = append(taken, b)
+	}
+	assert.DeepEqual(t, v.Stats(), PoolStats{MinRunners: 1, MaxRunners: 3, Runners: 3, Busy: 3})
+	assert.Assert(t, !v.sem.TryAcquire(1))
+
+	assert.NilError(t, taken[0].Validate("internal/testdata/valid-bag"))
+	assert.Assert(t, taken[0].runner.running.Load())
+	for _, b := range taken {
+		v.put(b)
+		v.release()
+	}
+	assert.DeepEqual(t, v.Stats(), PoolStats{MinRunners: 1, MaxRunners: 3, Runners: 3, Idle: 3})
+
+	v.reapIdle(time.Now())
+	assert.Equal(t, v.Stats().Runners, 3)
+
+	// The runners idling for longer are stopped first, down to the minimum.
+	v.reapIdle(time.Now().Add(time.Hour))
+	assert.DeepEqual(t, v.Stats(), PoolStats{MinRunners: 1, MaxRunners: 3, Runners: 1, Idle: 1})
+	assert.Assert(t, taken[0].runner == nil)
+	assert.Equal(t, v.idle[0].b, taken[2])
+
+	// New runners are created on demand again.
+	var g errgroup.Group
+	for range 6 {
+		g.Go(func() error {
+			return v.Validate("internal/testdata/valid-bag")
+		})
+	}
+	assert.NilError(t, g.Wait())
+	assert.Assert(t, v.Stats().Runners >= 1)
+	assert.Assert(t, v.Stats().Runners <= 3)
+}
+
+func TestValidatorStopsIdleRunners(t *testing.T) {
+	v, err := NewValidator(WithPoolBounds(0, 2), WithIdleTimeout(50*time.Millisecond), WithTempCacheDir())
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, v.Close())
+	})
+
+	assert.DeepEqual(t, v.Stats(), PoolStats{MaxRunners: 2})
+	assert.NilError(t, v.Validate("internal/testdata/valid-bag"))
+
+	poll.WaitOn(t, func(poll.LogT) poll.Result {
+		if n := v.Stats().Runners; n > 0 {
+			return poll.Continue("%d runners left", n)
+		}
+		return poll.Success()
+	}, poll.WithTimeout(5*time.Second))
+
+	assert.NilError(t, v.Validate("internal/testdata/valid-bag"))
+	assert.Equal(t, v.Stats().Runners, 1)
 }
 
 func validatorRuntimeDirs(v *Validator) []string {
@@ -1,12 +1,20 @@
 package bagit_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"log/slog"
+	"path/filepath"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/artefactual-labs/bagit-gython"
 	"golang.org/x/sync/errgroup"
 	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
 )
 
 func TestValidator(t *testing.T) {
@@ -36,8 +44,107 @@ func TestValidator(t *testing.T) {
 			assert.NilError(t, v.Close())
 		})
 
-		err = v.Validate("/tmp/691b8e7f-e6b7-41dd-bc47-868e2ff69333")
+		path := t.TempDir()
+		err = v.Validate(path)
 		assert.Assert(t, errors.Is(err, bagit.ErrInvalid))
+
+		var verr *bagit.ValidationError
+		assert.Assert(t, errors.As(err, &verr))
+		assert.Equal(t, verr.Report.Message, "Expected bagit.txt does not exist: "+filepath.Join(path, "bagit.txt"))
+	})
+
+	t.Run("Validates bag with options", func(t *testing.T) {
+		v, err := bagit.NewValidator(bagit.WithTempCacheDir())
+		assert.NilError(t, err)
+		t.Cleanup(func() {
+			assert.NilError(t, v.Close())
+		})
+
+		err = v.ValidateContext(context.Background(), "internal/testdata/valid-bag", bagit.WithFast())
+		assert.NilError(t, err)
+
+		err = v.TryValidate("internal/testdata/valid-bag", bagit.WithCompletenessOnly(), bagit.WithProcesses(1))
+		assert.NilError(t, err)
+	})
+
+	t.Run("Logs runner output", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+		v, err := bagit.NewValidator(bagit.WithTempCacheDir(), bagit.WithLogger(logger))
+		assert.NilError(t, err)
+
+		err = v.Validate("internal/testdata/valid-bag", bagit.WithProcesses(1))
+		assert.NilError(t, err)
+
+		// Close waits for the runner to exit, flushing its output.
+		assert.NilError(t, v.Close())
+
+		type record struct {
+			Level  string `json:"level"`
+			Msg    string `json:"msg"`
+			Logger string `json:"logger"`
+			Path   string `json:"path"`
+			Runner int    `json:"runner"`
+		}
+		var records []record
+		dec := json.NewDecoder(&buf)
+		for dec.More() {
+			var r record
+			assert.NilError(t, dec.Decode(&r))
+			records = append(records, r)
+		}
+
+		abs, err := filepath.Abs("internal/testdata/valid-bag/data/hola.txt")
+		assert.NilError(t, err)
+		assert.Assert(t, slices.Contains(records, record{
+			Level:  "INFO",
+			Msg:    "Verifying checksum for file " + abs,
+			Logger: "bagit",
+			Path:   "internal/testdata/valid-bag",
+			Runner: 0,
+		}), "records: %v", records)
+	})
+
+	t.Run("Makes bags on the shared pool", func(t *testing.T) {
+		v, err := bagit.NewValidator(bagit.WithPoolSize(2), bagit.WithTempCacheDir())
+		assert.NilError(t, err)
+		t.Cleanup(func() {
+			assert.NilError(t, v.Close())
+		})
+
+		var g errgroup.Group
+		for range 3 {
+			tmpDir := fs.NewDir(t, "", fs.WithFile("test.txt", "abcd"))
+			g.Go(func() error {
+				if _, err := v.MakeContext(context.Background(), tmpDir.Path(), bagit.MakeOptions{}); err != nil {
+					return err
+				}
+				return v.Validate(tmpDir.Path())
+			})
+		}
+		assert.NilError(t, g.Wait())
+
+		tmpDir := fs.NewDir(t, "", fs.WithFile("test.txt", "abcd"))
+		res, err := v.TryMake(tmpDir.Path(), bagit.MakeOptions{})
+		assert.NilError(t, err)
+		assert.Equal(t, res.PayloadOxum, bagit.PayloadOxum{Bytes: 4, Files: 1})
+	})
+
+	t.Run("Inspects bags", func(t *testing.T) {
+		v, err := bagit.NewValidator(bagit.WithTempCacheDir())
+		assert.NilError(t, err)
+		t.Cleanup(func() {
+			assert.NilError(t, v.Close())
+		})
+
+		bag, err := v.Inspect("internal/testdata/valid-bag")
+		assert.NilError(t, err)
+		assert.DeepEqual(t, bag.PayloadOxum, &bagit.PayloadOxum{Bytes: 0, Files: 1})
+		assert.DeepEqual(t, bag.Algorithms, []bagit.Algorithm{bagit.SHA256, bagit.SHA512})
+
+		_, err = v.TryInspect("internal/testdata/valid-bag")
+		assert.NilError(t, err)
 	})
 
 	t.Run("TryValidate validates bag without waiting", func(t *testing.T) {
@@ -67,6 +174,43 @@ func TestValidator(t *testing.T) {
 		assert.Error(t, err, "pool size must be greater than zero")
 	})
 
+	t.Run("Rejects invalid pool bounds", func(t *testing.T) {
+		_, err := bagit.NewValidator(bagit.WithPoolBounds(-1, 2))
+		assert.Error(t, err, "min runners must not be negative")
+
+		_, err = bagit.NewValidator(bagit.WithPoolBounds(3, 2))
+		assert.Error(t, err, "min runners must not exceed max runners")
+
+		_, err = bagit.NewValidator(bagit.WithIdleTimeout(-time.Second))
+		assert.Error(t, err, "idle timeout must not be negative")
+	})
+
+	t.Run("Rejects invalid health checks", func(t *testing.T) {
+		_, err := bagit.NewValidator(bagit.WithHealthCheck(-time.Second, time.Second))
+		assert.Error(t, err, "health check interval must not be negative")
+
+		_, err = bagit.NewValidator(bagit.WithHealthCheck(time.Second, 0))
+		assert.Error(t, err, "health check timeout must be greater than zero")
+	})
+
+	t.Run("Rejects negative runner limits", func(t *testing.T) {
+		_, err := bagit.NewValidator(bagit.WithMaxRequestsPerRunner(-1))
+		assert.Error(t, err, "max requests per runner must not be negative")
+
+		_, err = bagit.NewValidator(bagit.WithMaxRunnerRSS(-1))
+		assert.Error(t, err, "max runner RSS must not be negative")
+	})
+
+	t.Run("Rejects negative max restarts", func(t *testing.T) {
+		_, err := bagit.NewValidator(bagit.WithMaxRestarts(-1))
+		assert.Error(t, err, "max restarts must not be negative")
+	})
+
+	t.Run("Rejects negative request timeout", func(t *testing.T) {
+		_, err := bagit.NewValidator(bagit.WithRequestTimeout(-time.Second))
+		assert.Error(t, err, "request timeout must not be negative")
+	})
+
 	t.Run("Returns ErrClosed after close", func(t *testing.T) {
 		v, err := bagit.NewValidator(bagit.WithTempCacheDir())
 		assert.NilError(t, err)
@@ -76,5 +220,8 @@ func TestValidator(t *testing.T) {
 
 		err = v.Validate("internal/testdata/valid-bag")
 		assert.ErrorIs(t, err, bagit.ErrClosed)
+
+		_, err = v.Make(t.TempDir(), bagit.MakeOptions{})
+		assert.ErrorIs(t, err, bagit.ErrClosed)
 	})
 }
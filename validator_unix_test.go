@@ -0,0 +1,109 @@
+//go:build unix
+
+package bagit_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/artefactual-labs/bagit-gython"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+)
+
+// blockingBag returns a bag whose only payload file is a named pipe, hashing it
+// blocks until the pipe is opened for writing.
+func blockingBag(t *testing.T, v *bagit.Validator) string {
+	t.Helper()
+
+	tmpDir := fs.NewDir(t, "", fs.WithFile("fifo", ""))
+	_, err := v.Make(tmpDir.Path(), bagit.MakeOptions{})
+	assert.NilError(t, err)
+
+	fifo := tmpDir.Join("data", "fifo")
+	assert.NilError(t, os.Remove(fifo))
+	assert.NilError(t, syscall.Mkfifo(fifo, 0o600))
+
+	return tmpDir.Path()
+}
+
+func TestValidatorValidateContextCancelsInFlightValidation(t *testing.T) {
+	v, err := bagit.NewValidator(bagit.WithTempCacheDir())
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, v.Close())
+	})
+
+	path := blockingBag(t, v)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err = v.ValidateContext(ctx, path)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The pool slot has been replaced and accepts new commands.
+	assert.NilError(t, v.Validate("internal/testdata/valid-bag"))
+}
+
+func TestValidatorValidateContextCancelsSingleProcessValidation(t *testing.T) {
+	v, err := bagit.NewValidator(bagit.WithTempCacheDir())
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, v.Close())
+	})
+
+	path := blockingBag(t, v)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(500*time.Millisecond, cancel)
+
+	err = v.ValidateContext(ctx, path, bagit.WithProcesses(1))
+	assert.ErrorIs(t, err, context.Canceled)
+
+	assert.NilError(t, v.Validate("internal/testdata/valid-bag"))
+}
+
+func TestValidatorRequestTimeout(t *testing.T) {
+	v, err := bagit.NewValidator(bagit.WithTempCacheDir(), bagit.WithRequestTimeout(time.Second))
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, v.Close())
+	})
+
+	path := blockingBag(t, v)
+
+	err = v.Validate(path)
+	assert.ErrorIs(t, err, bagit.ErrTimeout)
+	assert.Assert(t, !errors.Is(err, context.DeadlineExceeded))
+
+	// A caller deadline shorter than the request timeout is reported as such.
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	err = v.ValidateContext(ctx, path, bagit.WithProcesses(1))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	assert.NilError(t, v.Validate("internal/testdata/valid-bag"))
+}
+
+func TestValidatorMetricsRunnerRestarts(t *testing.T) {
+	m := &recordedMetrics{}
+	v, err := bagit.NewValidator(bagit.WithTempCacheDir(), bagit.WithRequestTimeout(time.Second), bagit.WithMetrics(m))
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.NilError(t, v.Close())
+	})
+
+	path := blockingBag(t, v)
+	assert.ErrorIs(t, v.Validate(path), bagit.ErrTimeout)
+	assert.NilError(t, v.Validate("internal/testdata/valid-bag"))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	assert.Equal(t, m.restarts, 1)
+	assert.DeepEqual(t, m.validations, []bagit.ValidationOutcome{bagit.OutcomeError, bagit.OutcomeValid})
+}